@@ -3,6 +3,7 @@ package database
 import (
 	"log"
 	"medina-consultancy-api/models"
+	"medina-consultancy-api/pkg/textutil"
 	"os"
 	"time"
 
@@ -24,6 +25,8 @@ func ConnectWithDatabase() {
 		DSN: databaseConnection,
 	}), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
+		// unique violations come back as gorm.ErrDuplicatedKey
+		TranslateError: true,
 	})
 
 	if err != nil {
@@ -52,15 +55,55 @@ func ConnectWithDatabase() {
 		&models.Subscription{},
 		&models.IntegrationQuery{},
 		&models.Invoice{},
+		&models.SearchJob{},
+		&models.CreditTransaction{},
+		&models.ReconciliationReport{},
+		&models.CityRegion{},
+		&models.Place{},
+		&models.SearchResult{},
+		&models.ExportTemplate{},
+		&models.PlaceCacheEntry{},
+		&models.SavedSearch{},
+		&models.SavedSearchRun{},
+		&models.LeadList{},
+		&models.Lead{},
+		&models.LeadNote{},
+		&models.SeenPlace{},
+		&models.SearchBatch{},
+		&models.PricingConfig{},
 	); err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
+	dropReplacedIndexes()
 	seedCreditPackages()
+	seedCityRegions()
+	backfillSeenPlaces()
 
 	log.Println("Database connection established successfully.")
 }
 
+// dropReplacedIndexes drops unique indexes replaced by partial ones that leave out
+// soft-deleted rows, so that a deleted name can be used again.
+func dropReplacedIndexes() {
+	replaced := []struct {
+		model interface{}
+		name  string
+	}{
+		{&models.CityRegion{}, "idx_city_regions_city_name"},
+		{&models.ExportTemplate{}, "idx_export_templates_user_name"},
+	}
+
+	for _, index := range replaced {
+		if !DB.Migrator().HasIndex(index.model, index.name) {
+			continue
+		}
+		if err := DB.Migrator().DropIndex(index.model, index.name); err != nil {
+			log.Printf("Failed to drop index %s: %v", index.name, err)
+		}
+	}
+}
+
 func seedCreditPackages() {
 	packages := []models.CreditPackage{
 		{Name: "Starter", Credits: 10, Price: "15.90", Description: "10 credits for basic usage", Active: true},
@@ -76,3 +119,69 @@ func seedCreditPackages() {
 		}
 	}
 }
+
+func seedCityRegions() {
+	cities := map[string][]string{
+		"São Paulo": {
+			"Pinheiros", "Vila Mariana", "Moema", "Itaim Bibi", "Tatuapé", "Santana", "Lapa", "Butantã",
+			"Mooca", "Ipiranga", "Santo Amaro", "Penha", "Perdizes", "Vila Prudente", "Jabaquara",
+			"Campo Limpo", "Itaquera", "São Miguel Paulista", "Freguesia do Ó", "Brasilândia",
+		},
+		"Rio de Janeiro": {
+			"Copacabana", "Botafogo", "Tijuca", "Barra da Tijuca", "Centro", "Méier", "Madureira",
+			"Campo Grande", "Recreio dos Bandeirantes", "Jacarepaguá", "Leblon", "Ipanema", "Flamengo", "Bangu",
+		},
+		"Belo Horizonte": {
+			"Savassi", "Funcionários", "Centro", "Pampulha", "Barreiro", "Venda Nova", "Buritis",
+			"Santa Efigênia", "Padre Eustáquio", "Castelo",
+		},
+		"Curitiba": {
+			"Centro", "Batel", "Água Verde", "Portão", "Boqueirão", "Santa Felicidade", "Cabral",
+			"Bacacheri", "Cajuru", "Sítio Cercado",
+		},
+		"Porto Alegre": {
+			"Centro Histórico", "Moinhos de Vento", "Menino Deus", "Cidade Baixa", "Petrópolis",
+			"Sarandi", "Restinga", "Cristal", "Partenon", "Tristeza",
+		},
+	}
+
+	for city, regions := range cities {
+		cityKey := textutil.CityKey(city)
+
+		// admins may have edited the list, only seed cities without regions
+		var count int64
+		DB.Model(&models.CityRegion{}).Unscoped().Where("city = ?", cityKey).Count(&count)
+		if count > 0 {
+			continue
+		}
+
+		for position, name := range regions {
+			DB.Create(&models.CityRegion{City: cityKey, Name: name, Position: position, Active: true})
+		}
+	}
+}
+
+// backfillSeenPlaces fills the seen places from the stored results of earlier searches the
+// first time it runs, so only_new also knows what was delivered before it existed.
+func backfillSeenPlaces() {
+	var count int64
+	DB.Model(&models.SeenPlace{}).Limit(1).Count(&count)
+	if count > 0 {
+		return
+	}
+
+	err := DB.Exec(`INSERT INTO seen_places (user_id, subscription_id, place_id, first_search_id, created_at)
+		SELECT DISTINCT ON (owners.user_id, owners.subscription_id, search_results.place_id)
+			owners.user_id, owners.subscription_id, search_results.place_id, search_results.search_id, search_results.created_at
+		FROM search_results
+		JOIN (
+			SELECT search_id, user_id, 0 AS subscription_id FROM searches
+			UNION ALL
+			SELECT search_id, user_id, subscription_id FROM integration_queries
+		) owners ON owners.search_id = search_results.search_id
+		ORDER BY owners.user_id, owners.subscription_id, search_results.place_id, search_results.created_at
+		ON CONFLICT (user_id, subscription_id, place_id) DO NOTHING`).Error
+	if err != nil {
+		log.Printf("Failed to backfill seen places: %v", err)
+	}
+}
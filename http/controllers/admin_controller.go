@@ -0,0 +1,212 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"medina-consultancy-api/database"
+	"medina-consultancy-api/models"
+	"medina-consultancy-api/pkg/pricing"
+	"medina-consultancy-api/pkg/response"
+	"medina-consultancy-api/pkg/textutil"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CityRegionRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Position int    `json:"position"`
+	Active   *bool  `json:"active"`
+}
+
+// PricingRequest changes the credit pricing, fields left out keep their current value.
+type PricingRequest struct {
+	StandardCredits   *int     `json:"standard_credits"`
+	StandardQueries   *int     `json:"standard_queries"`
+	MinimumCredits    *int     `json:"minimum_credits"`
+	DetailsCredits    *int     `json:"details_credits"`
+	EnrichmentCredits *int     `json:"enrichment_credits"`
+	ResultCredits     *float64 `json:"result_credits"`
+	ResultCreditsCap  *int     `json:"result_credits_cap"`
+}
+
+func GetCityRegions(c *gin.Context) {
+	cityKey := textutil.CityKey(c.Param("city"))
+
+	var regions []models.CityRegion
+	if err := database.DB.Where("city = ?", cityKey).Order("position ASC, id ASC").Find(&regions).Error; err != nil {
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to fetch city regions")
+		return
+	}
+
+	response.SendGinResponse(c, http.StatusOK, regions, nil, "")
+}
+
+func CreateCityRegion(c *gin.Context) {
+	var req CityRegionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+
+	region := models.CityRegion{
+		City:     textutil.CityKey(c.Param("city")),
+		Name:     req.Name,
+		Position: req.Position,
+		Active:   req.Active == nil || *req.Active,
+	}
+
+	if region.City == "" {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, "City is required")
+		return
+	}
+
+	if err := database.DB.Create(&region).Error; err != nil {
+		sendCityRegionError(c, err, "Failed to create region")
+		return
+	}
+
+	response.SendGinResponse(c, http.StatusCreated, region, nil, "")
+}
+
+func UpdateCityRegion(c *gin.Context) {
+	var region models.CityRegion
+	if err := database.DB.First(&region, c.Param("id")).Error; err != nil {
+		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Region not found")
+		return
+	}
+
+	var req CityRegionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+
+	region.Name = req.Name
+	region.Position = req.Position
+	if req.Active != nil {
+		region.Active = *req.Active
+	}
+
+	if err := database.DB.Save(&region).Error; err != nil {
+		sendCityRegionError(c, err, "Failed to update region")
+		return
+	}
+
+	response.SendGinResponse(c, http.StatusOK, region, nil, "")
+}
+
+func DeleteCityRegion(c *gin.Context) {
+	result := database.DB.Delete(&models.CityRegion{}, c.Param("id"))
+	if result.Error != nil {
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to delete region")
+		return
+	}
+	if result.RowsAffected == 0 {
+		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Region not found")
+		return
+	}
+
+	response.SendGinResponse(c, http.StatusOK, gin.H{"deleted": true}, nil, "")
+}
+
+// sendCityRegionError answers a failed region write, a conflict only when the name is
+// already used in the city.
+func sendCityRegionError(c *gin.Context, err error, message string) {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		response.SendGinResponse(c, http.StatusConflict, nil, nil, "Region already exists for this city")
+		return
+	}
+	log.Printf("%s: %v", message, err)
+	response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, message)
+}
+
+// GetPricing returns the pricing in effect and the earlier versions saved by admins.
+func GetPricing(c *gin.Context) {
+	current, err := pricing.Current()
+	if err != nil {
+		log.Printf("Failed to load pricing: %v", err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to fetch pricing")
+		return
+	}
+
+	var history []models.PricingConfig
+	if err := database.DB.Order("id DESC").Limit(20).Find(&history).Error; err != nil {
+		log.Printf("Failed to load pricing history: %v", err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to fetch pricing")
+		return
+	}
+
+	response.SendGinResponse(c, http.StatusOK, gin.H{
+		"current":    current,
+		"is_default": current.ID == 0,
+		"history":    history,
+	}, nil, "")
+}
+
+// UpdatePricing saves a new pricing version, used by every search priced from now on.
+// Searches already running keep the price they were reserved at.
+func UpdatePricing(c *gin.Context) {
+	var req PricingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+
+	current, err := pricing.Current()
+	if err != nil {
+		log.Printf("Failed to load pricing: %v", err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to update pricing")
+		return
+	}
+
+	config := models.PricingConfig{
+		StandardCredits:   current.StandardCredits,
+		StandardQueries:   current.StandardQueries,
+		MinimumCredits:    current.MinimumCredits,
+		DetailsCredits:    current.DetailsCredits,
+		EnrichmentCredits: current.EnrichmentCredits,
+		ResultCredits:     current.ResultCredits,
+		ResultCreditsCap:  current.ResultCreditsCap,
+	}
+	if req.StandardCredits != nil {
+		config.StandardCredits = *req.StandardCredits
+	}
+	if req.StandardQueries != nil {
+		config.StandardQueries = *req.StandardQueries
+	}
+	if req.MinimumCredits != nil {
+		config.MinimumCredits = *req.MinimumCredits
+	}
+	if req.DetailsCredits != nil {
+		config.DetailsCredits = *req.DetailsCredits
+	}
+	if req.EnrichmentCredits != nil {
+		config.EnrichmentCredits = *req.EnrichmentCredits
+	}
+	if req.ResultCredits != nil {
+		config.ResultCredits = *req.ResultCredits
+	}
+	if req.ResultCreditsCap != nil {
+		config.ResultCreditsCap = *req.ResultCreditsCap
+	}
+	if userID, ok := c.Get("userID"); ok {
+		config.UpdatedBy = userID.(uint)
+	}
+
+	if err := pricing.Validate(config); err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+
+	if err := database.DB.Create(&config).Error; err != nil {
+		log.Printf("Failed to save pricing: %v", err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to update pricing")
+		return
+	}
+
+	log.Printf("Pricing %d saved by user %d", config.ID, config.UpdatedBy)
+
+	response.SendGinResponse(c, http.StatusOK, config, nil, "")
+}
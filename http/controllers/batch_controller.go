@@ -0,0 +1,363 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"medina-consultancy-api/database"
+	"medina-consultancy-api/models"
+	"medina-consultancy-api/pkg/billing"
+	"medina-consultancy-api/pkg/credits"
+	"medina-consultancy-api/pkg/export"
+	"medina-consultancy-api/pkg/response"
+	"medina-consultancy-api/pkg/search"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SearchBatchRequest struct {
+	Name     string               `json:"name"`
+	Requests []search.CityRequest `json:"requests"`
+	Request  *search.CityRequest  `json:"request"` // with cities, the same search run in each city
+	Cities   []string             `json:"cities"`
+	MaxPrice float64              `json:"max_price"` // integration only, rejects batches estimated above it
+}
+
+// searches expands the request into the searches of the batch and validates them.
+func (r SearchBatchRequest) searches() ([]search.CityRequest, error) {
+	requests := r.Requests
+	if r.Request != nil {
+		if len(requests) > 0 {
+			return nil, fmt.Errorf("send either requests or request with cities")
+		}
+		if len(r.Cities) == 0 {
+			return nil, fmt.Errorf("cities are required with request")
+		}
+		for _, city := range r.Cities {
+			cityReq := *r.Request
+			cityReq.City = city
+			cityReq.Area = nil
+			requests = append(requests, cityReq)
+		}
+	}
+
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("requests are required")
+	}
+	if len(requests) > search.MaxBatchItems() {
+		return nil, fmt.Errorf("a batch can have at most %d searches", search.MaxBatchItems())
+	}
+
+	fingerprints := make(map[string]int, len(requests))
+	for i, cityReq := range requests {
+		if err := cityReq.Validate(); err != nil {
+			return nil, fmt.Errorf("search %d: %w", i+1, err)
+		}
+		fingerprint := cityReq.Fingerprint()
+		if previous, ok := fingerprints[fingerprint]; ok {
+			return nil, fmt.Errorf("search %d repeats search %d", i+1, previous+1)
+		}
+		fingerprints[fingerprint] = i
+	}
+
+	return requests, nil
+}
+
+// searchBatchScope limits batches to the user, and to the subscription when called from
+// the integration API.
+func searchBatchScope(c *gin.Context, userID interface{}) *gorm.DB {
+	query := database.DB.Where("user_id = ?", userID)
+	if subscriptionID, ok := c.Get("subscriptionID"); ok {
+		return query.Where("subscription_id = ?", subscriptionID)
+	}
+	return query.Where("subscription_id IS NULL")
+}
+
+// CreateSearchBatch queues many searches at once, given as a list of requests or as one
+// request run in a list of cities. The whole batch is checked against the credits, or
+// against max_price from the integration API, before anything runs.
+func CreateSearchBatch(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	var req SearchBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, "Invalid request body")
+		return
+	}
+
+	searches, err := req.searches()
+	if err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+
+	batch := models.SearchBatch{
+		BatchID: uuid.New().String(),
+		UserID:  userID.(uint),
+		Name:    req.Name,
+		Items:   len(searches),
+	}
+	items := make([]search.BatchItem, len(searches))
+	for i, cityReq := range searches {
+		items[i] = search.BatchItem{SearchID: uuid.New().String(), Request: cityReq}
+	}
+	statusURL := fmt.Sprintf("%s/%s", c.Request.URL.Path, batch.BatchID)
+
+	if subscriptionID, ok := c.Get("subscriptionID"); ok {
+		id := subscriptionID.(uint)
+		batch.SubscriptionID = &id
+		createIntegrationSearchBatch(c, &batch, items, req.MaxPrice, statusURL)
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "User not found")
+		return
+	}
+
+	for i := range items {
+		quote, err := search.Price(items[i].Request)
+		if err != nil {
+			log.Printf("Failed to price batch search %d: %v", i+1, err)
+			response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to price search")
+			return
+		}
+		items[i].Quote = quote
+		items[i].Credits = quote.Reserved()
+		batch.CreditsUsed += items[i].Credits
+	}
+
+	if user.Credits < batch.CreditsUsed {
+		response.SendGinResponse(c, http.StatusPaymentRequired, gin.H{
+			"credits_required":  batch.CreditsUsed,
+			"credits_available": user.Credits,
+		}, nil, "Insufficient credits. Please purchase more credits to continue.")
+		return
+	}
+
+	reservations := make([]*credits.Reservation, 0, len(items))
+	releaseAll := func(reason string) {
+		for _, reservation := range reservations {
+			releaseSearchCredits(reservation, reason)
+		}
+	}
+
+	for _, item := range items {
+		reservation, err := credits.Reserve(user.ID, item.Credits, "search", item.SearchID, "Batch search")
+		if errors.Is(err, credits.ErrInsufficientCredits) {
+			releaseAll("Batch cancelled")
+			response.SendGinResponse(c, http.StatusPaymentRequired, gin.H{
+				"credits_required":  batch.CreditsUsed,
+				"credits_available": user.Credits,
+			}, nil, "Insufficient credits. Please purchase more credits to continue.")
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to debit credits for batch %s: %v", batch.BatchID, err)
+			releaseAll("Batch cancelled")
+			response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to debit credits")
+			return
+		}
+		reservations = append(reservations, reservation)
+		user.Credits = reservation.Balance
+	}
+
+	if err := search.CreateBatch(&batch, items); err != nil {
+		log.Printf("Failed to create search batch: %v", err)
+		releaseAll("Failed to enqueue batch")
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to enqueue batch")
+		return
+	}
+
+	log.Printf("Queued batch %s with %d search(es) for user %d, %d credit(s) reserved", batch.BatchID, batch.Items, user.ID, batch.CreditsUsed)
+
+	response.SendGinResponse(c, http.StatusAccepted, gin.H{
+		"batch_id":          batch.BatchID,
+		"status":            "queued",
+		"items":             items,
+		"credits_used":      batch.CreditsUsed,
+		"credits_remaining": user.Credits,
+		"status_url":        statusURL,
+	}, nil, "")
+}
+
+// createIntegrationSearchBatch queues a batch of integration queries, each billed like a
+// single query once it runs.
+func createIntegrationSearchBatch(c *gin.Context, batch *models.SearchBatch, items []search.BatchItem, maxPrice float64, statusURL string) {
+	queryCount, _, err := billing.MonthlyQueryCounts(*batch.SubscriptionID, time.Now().Format("2006-01"))
+	if err != nil {
+		log.Printf("Failed to count integration queries: %v", err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to estimate batch price")
+		return
+	}
+
+	// the whole month is billed at the tier reached with the batch
+	unitPrice := CalculateUnitPrice(int(queryCount) + len(items))
+	estimatedPrice := unitPrice * float64(len(items))
+
+	billingEstimate := gin.H{
+		"queries_this_month": queryCount,
+		"batch_queries":      len(items),
+		"unit_price":         fmt.Sprintf("%.2f", unitPrice),
+		"estimated_price":    fmt.Sprintf("%.2f", estimatedPrice),
+	}
+
+	if maxPrice > 0 && estimatedPrice > maxPrice {
+		response.SendGinResponse(c, http.StatusPaymentRequired, gin.H{"billing": billingEstimate}, nil, "Estimated batch price is above max_price")
+		return
+	}
+
+	if err := search.CreateBatch(batch, items); err != nil {
+		log.Printf("Failed to create integration search batch: %v", err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to enqueue batch")
+		return
+	}
+
+	log.Printf("Queued integration batch %s with %d quer(ies) for subscription %d", batch.BatchID, batch.Items, *batch.SubscriptionID)
+
+	response.SendGinResponse(c, http.StatusAccepted, gin.H{
+		"batch_id":   batch.BatchID,
+		"status":     "queued",
+		"items":      items,
+		"billing":    billingEstimate,
+		"status_url": statusURL,
+	}, nil, "")
+}
+
+// GetSearchBatch reports the status of a batch and of each of its searches.
+func GetSearchBatch(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	batch, jobs, ok := findSearchBatch(c, userID)
+	if !ok {
+		return
+	}
+
+	counts := map[string]int{"queued": 0, "running": 0, "done": 0, "failed": 0}
+	totalResults := 0
+	items := make([]gin.H, len(jobs))
+	for i, job := range jobs {
+		var cityReq search.CityRequest
+		if err := json.Unmarshal([]byte(job.Request), &cityReq); err != nil {
+			log.Printf("Invalid request stored for search job %s: %v", job.SearchID, err)
+		}
+
+		counts[job.Status]++
+		totalResults += job.Results
+
+		items[i] = gin.H{
+			"search_id":     job.SearchID,
+			"query":         cityReq.Search,
+			"city":          cityReq.Location(),
+			"status":        job.Status,
+			"regions_total": job.RegionsTotal,
+			"regions_done":  job.RegionsDone,
+			"results":       job.Results,
+			"excluded_seen": job.ExcludedSeen,
+			"credits_used":  job.CreditsUsed,
+			"error":         job.Error,
+		}
+	}
+
+	response.SendGinResponse(c, http.StatusOK, gin.H{
+		"batch":         batch,
+		"status":        search.BatchStatus(jobs),
+		"counts":        counts,
+		"total_results": totalResults,
+		"items":         items,
+		"export_url":    fmt.Sprintf("%s/export", c.Request.URL.Path),
+	}, nil, "")
+}
+
+// ExportSearchBatch downloads the results of the finished searches of a batch merged in
+// one file, each place once, with ?format= and the export options of single searches.
+func ExportSearchBatch(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	format, err := export.LookupFormat(c.DefaultQuery("format", "csv"))
+	if err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, "Unsupported format. Use csv, xlsx, json, ndjson or vcf")
+		return
+	}
+
+	opts, err := exportOptionsFromQuery(c, userID)
+	if err != nil {
+		sendExportOptionsError(c, err)
+		return
+	}
+
+	batch, jobs, ok := findSearchBatch(c, userID)
+	if !ok {
+		return
+	}
+
+	var searchIDs []string
+	for _, job := range jobs {
+		if job.Status == "done" {
+			searchIDs = append(searchIDs, job.SearchID)
+		}
+	}
+	if len(searchIDs) == 0 {
+		response.SendGinResponse(c, http.StatusConflict, nil, nil, "No search of this batch has finished yet")
+		return
+	}
+
+	results, err := search.BatchResults(searchIDs)
+	if err != nil {
+		log.Printf("Failed to load results of batch %s: %v", batch.BatchID, err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to read search results")
+		return
+	}
+
+	if c.Query("only_mobile") == "true" {
+		results = search.OnlyMobile(results)
+	}
+
+	data, err := format.Render(results, opts)
+	if err != nil {
+		log.Printf("Failed to render %s export for batch %s: %v", format.Extension, batch.BatchID, err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to generate export")
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=batch_%s.%s", batch.BatchID, format.Extension))
+	c.Header("Content-Type", format.ContentType)
+	c.Data(http.StatusOK, format.ContentType, data)
+}
+
+// findSearchBatch loads the batch in :batchId with its jobs, answering the request itself
+// when it cannot.
+func findSearchBatch(c *gin.Context, userID interface{}) (*models.SearchBatch, []models.SearchJob, bool) {
+	var batch models.SearchBatch
+	if err := searchBatchScope(c, userID).Where("batch_id = ?", c.Param("batchId")).First(&batch).Error; err != nil {
+		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Batch not found")
+		return nil, nil, false
+	}
+
+	var jobs []models.SearchJob
+	if err := database.DB.Where("batch_id = ?", batch.BatchID).Order("id ASC").Find(&jobs).Error; err != nil {
+		log.Printf("Failed to fetch jobs of batch %s: %v", batch.BatchID, err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to fetch batch")
+		return nil, nil, false
+	}
+
+	return &batch, jobs, true
+}
@@ -2,13 +2,18 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"medina-consultancy-api/database"
 	"medina-consultancy-api/models"
 	mercadopago "medina-consultancy-api/pkg/mercado_pago"
+	"medina-consultancy-api/pkg/pagination"
+	"medina-consultancy-api/pkg/payments"
 	"medina-consultancy-api/pkg/response"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -178,33 +183,21 @@ func CheckPaymentStatus(c *gin.Context) {
 	}
 
 	// poll for current status
+	status := ""
 	if order.MercadoPagoID != "" {
 		paymentID, err := strconv.ParseInt(order.MercadoPagoID, 10, 64)
 		if err == nil {
 			ctx := context.Background()
-			status, err := mercadopago.GetPaymentStatus(ctx, int(paymentID))
-			if err == nil && status != order.Status {
-				order.Status = status
-				database.DB.Model(&order).Update("status", status)
+			if current, err := mercadopago.GetPaymentStatus(ctx, int(paymentID)); err == nil {
+				status = current
 			}
 		}
 	}
 
-	if order.Status == "approved" && !order.CreditsAdded {
-		var user models.User
-		if err := database.DB.First(&user, userID).Error; err != nil {
-			response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to find user")
-			return
-		}
-
-		user.Credits += order.CreditPackage.Credits
-		if err := database.DB.Save(&user).Error; err != nil {
-			response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to add credits")
-			return
-		}
-
-		order.CreditsAdded = true
-		database.DB.Model(&order).Update("credits_added", true)
+	if _, err := payments.ApplyOrderStatus(&order, status); err != nil {
+		log.Printf("Failed to apply payment status for order %d: %v", order.ID, err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to add credits")
+		return
 	}
 
 	response.SendGinResponse(c, http.StatusOK, gin.H{
@@ -215,6 +208,103 @@ func CheckPaymentStatus(c *gin.Context) {
 	}, nil, "")
 }
 
+func MercadoPagoWebhook(c *gin.Context) {
+	var notification struct {
+		Type   string `json:"type"`
+		Action string `json:"action"`
+		Data   struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := c.ShouldBindJSON(&notification); err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, "Invalid notification body")
+		return
+	}
+
+	// the signature is computed over the data.id query parameter
+	dataID := c.Query("data.id")
+	if dataID == "" {
+		dataID = notification.Data.ID
+	}
+
+	if err := mercadopago.ValidateWebhookSignature(c.GetHeader("x-signature"), c.GetHeader("x-request-id"), dataID); err != nil {
+		log.Printf("Rejected Mercado Pago notification: %v", err)
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "Invalid signature")
+		return
+	}
+
+	notificationType := notification.Type
+	if notificationType == "" {
+		notificationType = c.Query("type")
+	}
+	if notificationType != "payment" {
+		response.SendGinResponse(c, http.StatusOK, gin.H{"ignored": true}, nil, "")
+		return
+	}
+
+	paymentID, err := strconv.ParseInt(dataID, 10, 64)
+	if err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, "Invalid payment ID")
+		return
+	}
+
+	ctx := context.Background()
+	payment, err := mercadopago.GetPayment(ctx, int(paymentID))
+	if err != nil {
+		log.Printf("Failed to fetch payment %d: %v", paymentID, err)
+		response.SendGinResponse(c, http.StatusBadGateway, nil, nil, "Failed to fetch payment")
+		return
+	}
+
+	switch {
+	case strings.HasPrefix(payment.ExternalReference, "order_"):
+		var order models.Order
+		if err := database.DB.Preload("CreditPackage").Where("external_reference = ?", payment.ExternalReference).First(&order).Error; err != nil {
+			response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Order not found")
+			return
+		}
+
+		if order.MercadoPagoID == "" {
+			database.DB.Model(&order).Update("mercado_pago_id", payment.ID)
+		}
+
+		credited, err := payments.ApplyOrderStatus(&order, payment.Status)
+		if err != nil {
+			log.Printf("Failed to apply payment %s to order %d: %v", payment.ID, order.ID, err)
+			response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to process payment")
+			return
+		}
+
+		response.SendGinResponse(c, http.StatusOK, gin.H{
+			"order_id":      order.ID,
+			"status":        order.Status,
+			"credits_added": order.CreditsAdded,
+			"credited_now":  credited,
+		}, nil, "")
+
+	case strings.HasPrefix(payment.ExternalReference, "invoice_"):
+		var invoice models.Invoice
+		if err := database.DB.Where("external_reference = ? OR mercado_pago_id = ?", payment.ExternalReference, payment.ID).First(&invoice).Error; err != nil {
+			response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Invoice not found")
+			return
+		}
+
+		if err := payments.ApplyInvoiceStatus(&invoice, payment.ID, payment.Status); err != nil {
+			log.Printf("Failed to apply payment %s to invoice %d: %v", payment.ID, invoice.ID, err)
+			response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to process payment")
+			return
+		}
+
+		response.SendGinResponse(c, http.StatusOK, gin.H{
+			"invoice_id": invoice.ID,
+			"status":     invoice.Status,
+		}, nil, "")
+
+	default:
+		response.SendGinResponse(c, http.StatusOK, gin.H{"ignored": true}, nil, "")
+	}
+}
+
 func GetOrderStatus(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -256,13 +346,26 @@ func GetUserOrders(c *gin.Context) {
 		return
 	}
 
-	var orders []models.Order
-	if err := database.DB.Preload("CreditPackage").Where("user_id = ?", userID).Order("created_at DESC").Find(&orders).Error; err != nil {
+	params, err := pagination.Parse(c)
+	if err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+
+	orders, meta, err := pagination.Find[models.Order](database.DB.Preload("CreditPackage").Where("user_id = ?", userID), params, pagination.Columns{
+		Status: "status",
+	})
+	if errors.Is(err, pagination.ErrInvalidSort) {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to fetch orders: %v", err)
 		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to fetch orders")
 		return
 	}
 
-	response.SendGinResponse(c, http.StatusOK, orders, nil, "")
+	response.SendGinResponse(c, http.StatusOK, orders, meta, "")
 }
 
 func GetUserCredits(c *gin.Context) {
@@ -282,3 +385,32 @@ func GetUserCredits(c *gin.Context) {
 		"credits": user.Credits,
 	}, nil, "")
 }
+
+func GetCreditHistory(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	params, err := pagination.Parse(c)
+	if err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+
+	transactions, meta, err := pagination.Find[models.CreditTransaction](database.DB.Where("user_id = ?", userID), params, pagination.Columns{
+		Status: "status",
+	})
+	if errors.Is(err, pagination.ErrInvalidSort) {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to fetch credit history: %v", err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to fetch credit history")
+		return
+	}
+
+	response.SendGinResponse(c, http.StatusOK, transactions, meta, "")
+}
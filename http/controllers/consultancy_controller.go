@@ -1,56 +1,31 @@
 package controllers
 
 import (
-	"encoding/csv"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"medina-consultancy-api/database"
 	"medina-consultancy-api/models"
+	"medina-consultancy-api/pkg/billing"
+	"medina-consultancy-api/pkg/credits"
+	"medina-consultancy-api/pkg/export"
+	"medina-consultancy-api/pkg/pagination"
+	getParams "medina-consultancy-api/pkg/params"
+	"medina-consultancy-api/pkg/phone"
+	"medina-consultancy-api/pkg/places"
 	"medina-consultancy-api/pkg/response"
+	"medina-consultancy-api/pkg/search"
 	"medina-consultancy-api/pkg/supabase"
 	"net/http"
-	"net/url"
-	"os"
-	"strings"
-	"sync"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
-const CreditsPerSearch = 10
-
-type CityRequest struct {
-	Search     string   `json:"search"`
-	City       string   `json:"city"`
-	PlaceType  string   `json:"place_type"`
-	MinRating  float64  `json:"min_rating"`
-	PriceLevel int      `json:"price_level"` // 0-4 (0=free, 4=very expensive)
-	Keywords   []string `json:"keywords"`
-}
-
-type PlaceDetails struct {
-	Name                 string        `json:"name"`
-	FormattedAddress     string        `json:"formatted_address"`
-	FormattedPhoneNumber string        `json:"formatted_phone_number"`
-	Website              string        `json:"website"`
-	URL                  string        `json:"url"`
-	Rating               float64       `json:"rating"`
-	UserRatingsTotal     int           `json:"user_ratings_total"`
-	PriceLevel           int           `json:"price_level"`
-	BusinessStatus       string        `json:"business_status"`
-	OpeningHours         *OpeningHours `json:"opening_hours"`
-	Types                []string      `json:"types"`
-}
-
-type OpeningHours struct {
-	OpenNow     bool     `json:"open_now"`
-	WeekdayText []string `json:"weekday_text"`
-}
-
 func GetPlaceTypes(c *gin.Context) {
 	placeTypes := []map[string]string{
 		{"value": "", "label": "Todos"},
@@ -148,160 +123,352 @@ func FindLocationsBasedOnAddress(c *gin.Context) {
 		return
 	}
 
-	if user.Credits < CreditsPerSearch {
-		response.SendGinResponse(c, http.StatusPaymentRequired, gin.H{
-			"credits_required":  CreditsPerSearch,
-			"credits_available": user.Credits,
-		}, nil, "Insufficient credits. Please purchase more credits to continue.")
-		return
-	}
-
-	var cityReq CityRequest
+	var cityReq search.CityRequest
 	if err := c.ShouldBindJSON(&cityReq); err != nil {
 		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, "Invalid request body")
 		return
 	}
 
-	if cityReq.Search == "" || cityReq.City == "" {
-		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, "Search and city fields are required")
+	if err := cityReq.Validate(); err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
 		return
 	}
 
-	apiKey := os.Getenv("GOOGLE_PLACES_API_KEY")
-	if apiKey == "" {
-		log.Printf("API key is missing")
-		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "API key is missing")
-		return
+	// the results of an identical search were all delivered already
+	if !cityReq.ForceRefresh && !cityReq.OnlyNew {
+		original, err := search.FindReusableSearch(user.ID, cityReq.Fingerprint())
+		if err != nil {
+			log.Printf("Failed to look up reusable search: %v", err)
+		} else if original != nil {
+			reuseSearch(c, &user, original)
+			return
+		}
 	}
 
-	user.Credits -= CreditsPerSearch
-	if err := database.DB.Save(&user).Error; err != nil {
-		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to debit credits")
+	quote, err := search.Price(cityReq)
+	if err != nil {
+		log.Printf("Failed to price search: %v", err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to price search")
 		return
 	}
+	// the result charge is only known once the search ran, its cap is reserved up front
+	cost := quote.Reserved()
 
-	log.Printf("Debited %d credit(s) from user %d. Remaining: %d", CreditsPerSearch, user.ID, user.Credits)
-
-	uniquePlaces := make(map[string]PlaceDetails)
-	var mutex sync.Mutex
-	var wg sync.WaitGroup
-
-	regions := []string{"", "centro", "norte", "sul", "leste", "oeste"}
-
-	for _, region := range regions {
-		wg.Add(1)
-		go func(region string) {
-			defer wg.Done()
-
-			searchQuery := cityReq.Search
-			cityQuery := cityReq.City
-
-			if len(cityReq.Keywords) > 0 {
-				searchQuery = fmt.Sprintf("%s %s", searchQuery, strings.Join(cityReq.Keywords, " "))
-			}
-
-			if region != "" {
-				cityQuery = fmt.Sprintf("%s %s", cityReq.City, region)
-			}
+	if user.Credits < cost {
+		response.SendGinResponse(c, http.StatusPaymentRequired, gin.H{
+			"credits_required":  cost,
+			"credits_available": user.Credits,
+		}, nil, "Insufficient credits. Please purchase more credits to continue.")
+		return
+	}
 
-			fetchPlacesForQuery(searchQuery, cityQuery, apiKey, cityReq.PlaceType, uniquePlaces, &mutex)
-		}(region)
+	provider, err := places.NewProviderFromEnv()
+	if err != nil {
+		log.Printf("Failed to configure place provider: %v", err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Place provider is not configured")
+		return
 	}
 
-	wg.Wait()
+	searchID := uuid.New().String()
 
-	var search []PlaceDetails
-	for _, place := range uniquePlaces {
-		search = append(search, place)
+	reservation, err := credits.Reserve(user.ID, cost, "search", searchID, "Search")
+	if errors.Is(err, credits.ErrInsufficientCredits) {
+		response.SendGinResponse(c, http.StatusPaymentRequired, gin.H{
+			"credits_required":  cost,
+			"credits_available": user.Credits,
+		}, nil, "Insufficient credits. Please purchase more credits to continue.")
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to debit credits: %v", err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to debit credits")
+		return
 	}
+	user.Credits = reservation.Balance
 
-	log.Printf("Total de resultados únicos encontrados: %d", len(search))
+	log.Printf("Reserved %d credit(s) from user %d. Remaining: %d", cost, user.ID, user.Credits)
 
-	searchID := uuid.New().String()
-	fileName := fmt.Sprintf("searches/%s.csv", searchID)
+	if async, _ := getParams.GetParams(c, "async"); async == "true" {
+		job, err := search.EnqueueJob(user.ID, searchID, cityReq, quote)
+		if err != nil {
+			log.Printf("Failed to enqueue search job: %v", err)
+			releaseSearchCredits(reservation, "Failed to enqueue search")
+			response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to enqueue search")
+			return
+		}
 
-	csvData, err := generateCSV(search)
-	if err != nil {
-		log.Printf("Failed to generate CSV: %v", err)
-		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to generate CSV")
+		response.SendGinResponse(c, http.StatusAccepted, gin.H{
+			"search_id":         job.SearchID,
+			"status":            job.Status,
+			"credits_used":      cost,
+			"credits_remaining": user.Credits,
+			"pricing":           quote,
+			"status_url":        fmt.Sprintf("/api/v1/consultancy/search/%s", job.SearchID),
+		}, nil, "")
 		return
 	}
 
-	supabaseClient, err := supabase.NewClient()
+	// stops calling the provider when the client disconnects or the API timeout is near
+	ctx, cancel := context.WithTimeout(c.Request.Context(), search.SyncTimeout())
+	defer cancel()
+
+	result, err := search.Run(ctx, provider, cityReq, nil)
 	if err != nil {
-		log.Printf("Failed to create Supabase client: %v", err)
-		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to initialize storage")
+		log.Printf("Search failed: %v", err)
+		releaseSearchCredits(reservation, "Search failed")
+		if errors.Is(err, context.DeadlineExceeded) {
+			response.SendGinResponse(c, http.StatusGatewayTimeout, nil, nil, "Search took too long, run it with ?async=true")
+			return
+		}
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Search failed")
 		return
 	}
+	if cityReq.OnlyNew {
+		if err := search.ExcludeSeen(result, provider.Name(), search.Owner{UserID: user.ID}); err != nil {
+			log.Printf("Failed to exclude seen places: %v", err)
+			releaseSearchCredits(reservation, "Search failed")
+			response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Search failed")
+			return
+		}
+	}
+	results := result.Places
 
-	bucketURL, err := supabaseClient.UploadFile(fileName, csvData, "text/csv")
+	log.Printf("Total de resultados únicos encontrados: %d", len(results))
+
+	fileName, bucketURL, err := search.UploadCSV(searchID, results)
 	if err != nil {
-		log.Printf("Failed to upload CSV to Supabase: %v", err)
+		log.Printf("Failed to store search results: %v", err)
+		releaseSearchCredits(reservation, "Failed to save search results")
 		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to save search results")
 		return
 	}
 
+	refund := search.SearchRefund(result, quote)
+
 	searchRecord := models.Search{
-		UserID:    userID.(uint),
-		SearchID:  searchID,
-		Query:     cityReq.Search,
-		City:      cityReq.City,
-		BucketURL: bucketURL,
-		FileName:  fileName,
-		Results:   len(search),
+		UserID:          userID.(uint),
+		SearchID:        searchID,
+		Query:           cityReq.Search,
+		City:            cityReq.Location(),
+		BucketURL:       bucketURL,
+		FileName:        fileName,
+		Results:         len(results),
+		Fingerprint:     cityReq.Fingerprint(),
+		Warnings:        result.Meta.Warnings,
+		DetailsMissing:  result.Meta.DetailsMissing,
+		CreditsRefunded: refund,
+		ExcludedSeen:    result.Meta.ExcludedSeen,
 	}
 
-	if err := database.DB.Create(&searchRecord).Error; err != nil {
+	if err := search.SaveSearch(&searchRecord, provider.Name(), results); err != nil {
 		log.Printf("Failed to save search record: %v", err)
+		releaseSearchCredits(reservation, "Failed to save search record")
 		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to save search record")
 		return
 	}
 
+	creditsUsed, balance := search.SettleSearchCredits(reservation.ReferenceType, searchID, cost, refund)
+	if balance >= 0 {
+		user.Credits = balance
+	}
+
 	response.SendGinResponse(c, http.StatusOK, gin.H{
 		"search_id":         searchID,
-		"results":           search,
-		"total_results":     len(search),
-		"credits_used":      CreditsPerSearch,
+		"results":           results,
+		"total_results":     len(results),
+		"credits_used":      creditsUsed,
 		"credits_remaining": user.Credits,
+		"pricing":           quote,
 		"download_url":      fmt.Sprintf("/api/v1/consultancy/search/%s/csv", searchID),
-	}, nil, "")
+	}, result.Meta, "")
 }
 
-func generateCSV(places []PlaceDetails) ([]byte, error) {
-	var buf strings.Builder
+// EstimateSearch tells what a search would cost and roughly how many places it would find,
+// without running it. Only the database is read, never the place provider.
+func EstimateSearch(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "User not found")
+		return
+	}
+
+	var cityReq search.CityRequest
+	if err := c.ShouldBindJSON(&cityReq); err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, "Invalid request body")
+		return
+	}
+
+	if err := cityReq.Validate(); err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+
+	quote, err := search.Price(cityReq)
+	if err != nil {
+		log.Printf("Failed to price search: %v", err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to price search")
+		return
+	}
+	cost := quote.Reserved()
+
+	// credits_required is reserved, the unused part of the result charge is given back
+	data := gin.H{
+		"credits_required":  cost,
+		"credits_minimum":   quote.Base(),
+		"credits_available": user.Credits,
+		"breakdown":         quote,
+	}
+	reused := false
+
+	// the same lookup as FindLocationsBasedOnAddress, which would charge the re-use price
+	if !cityReq.ForceRefresh && !cityReq.OnlyNew {
+		original, err := search.FindReusableSearch(user.ID, cityReq.Fingerprint())
+		if err != nil {
+			log.Printf("Failed to look up reusable search: %v", err)
+		} else if original != nil {
+			reused = true
+			cost = search.ReuseCredits()
+			data["credits_required"] = cost
+			data["credits_minimum"] = cost
+			data["reuse"] = gin.H{
+				"reused_from":        original.SearchID,
+				"reused_searched_at": original.CreatedAt,
+				"results":            original.Results,
+			}
+		}
+	}
+	data["sufficient_credits"] = user.Credits >= cost
+
+	if estimate, err := search.EstimateResults(cityReq); err != nil {
+		log.Printf("Failed to estimate search results: %v", err)
+	} else {
+		data["approximate_results"] = estimate.Results
+		data["estimate_basis"] = estimate.Basis
+		data["estimate_samples"] = estimate.Samples
+		if !reused && quote.ResultCap > 0 {
+			data["credits_expected"] = quote.Charge(estimate.Results)
+		}
+	}
+
+	var subscription models.Subscription
+	if err := database.DB.Where("user_id = ? AND status = ?", user.ID, "active").First(&subscription).Error; err == nil {
+		queryCount, _, err := billing.MonthlyQueryCounts(subscription.ID, time.Now().Format("2006-01"))
+		if err != nil {
+			log.Printf("Failed to count integration queries: %v", err)
+		} else {
+			data["integration"] = gin.H{
+				"queries_this_month": queryCount,
+				"unit_price":         fmt.Sprintf("%.2f", CalculateUnitPrice(int(queryCount)+1)),
+				"reuse_price":        fmt.Sprintf("%.2f", billing.ReusePrice()),
+			}
+		}
+	}
 
-	buf.WriteString("\xEF\xBB\xBF")
+	response.SendGinResponse(c, http.StatusOK, data, nil, "")
+}
 
-	writer := csv.NewWriter(&buf)
-	writer.Comma = ';'
+// reuseSearch answers with the results of a recent identical search, charged at the re-use
+// price instead of running the search again.
+func reuseSearch(c *gin.Context, user *models.User, original *models.Search) {
+	cost := search.ReuseCredits()
+	if user.Credits < cost {
+		response.SendGinResponse(c, http.StatusPaymentRequired, gin.H{
+			"credits_required":  cost,
+			"credits_available": user.Credits,
+		}, nil, "Insufficient credits. Please purchase more credits to continue.")
+		return
+	}
 
-	header := []string{"Nome", "Endereço", "Telefone", "Website"}
-	if err := writer.Write(header); err != nil {
-		return nil, fmt.Errorf("failed to write CSV header: %w", err)
+	results, _, err := search.LoadResults(original.SearchID)
+	if err != nil {
+		log.Printf("Failed to load results of search %s for re-use: %v", original.SearchID, err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to load search results")
+		return
 	}
 
-	for _, place := range places {
+	searchID := uuid.New().String()
 
-		row := []string{
-			place.Name,
-			place.FormattedAddress,
-			place.FormattedPhoneNumber,
-			place.Website,
+	var reservation *credits.Reservation
+	if cost > 0 {
+		reservation, err = credits.Reserve(user.ID, cost, "search", searchID, "Search re-use")
+		if errors.Is(err, credits.ErrInsufficientCredits) {
+			response.SendGinResponse(c, http.StatusPaymentRequired, gin.H{
+				"credits_required":  cost,
+				"credits_available": user.Credits,
+			}, nil, "Insufficient credits. Please purchase more credits to continue.")
+			return
 		}
-		if err := writer.Write(row); err != nil {
-			return nil, fmt.Errorf("failed to write CSV row: %w", err)
+		if err != nil {
+			log.Printf("Failed to debit credits: %v", err)
+			response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to debit credits")
+			return
+		}
+		user.Credits = reservation.Balance
+	}
+
+	if _, err := search.ReuseSearch(original, searchID); err != nil {
+		log.Printf("Failed to save re-used search: %v", err)
+		if reservation != nil {
+			releaseSearchCredits(reservation, "Failed to save search record")
 		}
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to save search record")
+		return
 	}
 
-	writer.Flush()
-	if err := writer.Error(); err != nil {
-		return nil, fmt.Errorf("CSV writer error: %w", err)
+	if reservation != nil {
+		if err := reservation.Commit(); err != nil {
+			log.Printf("Failed to commit credit reservation for search %s: %v", searchID, err)
+		}
 	}
 
-	return []byte(buf.String()), nil
+	log.Printf("Re-used search %s as %s for user %d", original.SearchID, searchID, user.ID)
+
+	response.SendGinResponse(c, http.StatusOK, gin.H{
+		"search_id":          searchID,
+		"results":            results,
+		"total_results":      len(results),
+		"credits_used":       cost,
+		"credits_remaining":  user.Credits,
+		"download_url":       fmt.Sprintf("/api/v1/consultancy/search/%s/csv", searchID),
+		"reused_from":        original.SearchID,
+		"reused_searched_at": original.CreatedAt,
+	}, nil, "")
+}
+
+func releaseSearchCredits(reservation *credits.Reservation, reason string) {
+	if _, err := reservation.Release(reason); err != nil {
+		log.Printf("Failed to refund credits for search %s: %v", reservation.ReferenceID, err)
+	}
 }
 
 func DownloadSearchCSV(c *gin.Context) {
+	sendSearchExport(c, export.Formats["csv"])
+}
+
+func ExportSearch(c *gin.Context) {
+	formatName, ok := getParams.GetParams(c, "format")
+	if !ok {
+		formatName = "csv"
+	}
+
+	format, err := export.LookupFormat(formatName)
+	if err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, "Unsupported format. Use csv, xlsx, json, ndjson or vcf")
+		return
+	}
+
+	sendSearchExport(c, format)
+}
+
+// sendSearchExport renders the results of the search in :searchId as an attachment, using
+// the export template and options given in the query string.
+func sendSearchExport(c *gin.Context, format export.Format) {
 	userID, exists := c.Get("userID")
 	if !exists {
 		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
@@ -314,180 +481,217 @@ func DownloadSearchCSV(c *gin.Context) {
 		return
 	}
 
+	opts, err := exportOptionsFromQuery(c, userID)
+	if err != nil {
+		sendExportOptionsError(c, err)
+		return
+	}
+
 	var searchRecord models.Search
 	if err := database.DB.Where("search_id = ? AND user_id = ?", searchID, userID).First(&searchRecord).Error; err != nil {
 		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Search not found")
 		return
 	}
 
-	supabaseClient, err := supabase.NewClient()
+	results, err := loadSearchResults(searchRecord)
 	if err != nil {
-		log.Printf("Failed to create Supabase client: %v", err)
-		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to initialize storage")
+		log.Printf("Failed to load results for search %s: %v", searchID, err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to read search results")
 		return
 	}
 
-	csvData, err := supabaseClient.DownloadFile(searchRecord.FileName)
+	if c.Query("only_mobile") == "true" {
+		results = search.OnlyMobile(results)
+	}
+	if c.Query("only_new") == "true" {
+		results, _, err = search.FirstSeenIn(search.Owner{UserID: searchRecord.UserID}, searchID, results)
+		if err != nil {
+			log.Printf("Failed to exclude seen places from search %s: %v", searchID, err)
+			response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to read search results")
+			return
+		}
+	}
+
+	data, err := format.Render(results, opts)
 	if err != nil {
-		log.Printf("Failed to download CSV from Supabase: %v", err)
-		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to download file")
+		log.Printf("Failed to render %s export for search %s: %v", format.Extension, searchID, err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to generate export")
 		return
 	}
 
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s_%s.csv", searchRecord.Query, searchRecord.City))
-	c.Header("Content-Type", "text/csv")
-	c.Data(http.StatusOK, "text/csv", csvData)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s_%s.%s", searchRecord.Query, searchRecord.City, format.Extension))
+	c.Header("Content-Type", format.ContentType)
+	c.Data(http.StatusOK, format.ContentType, data)
 }
 
-func GetUserSearches(c *gin.Context) {
+// loadSearchResults reads the persisted results of a search, falling back to its CSV for
+// searches made before results were stored in the database.
+func loadSearchResults(searchRecord models.Search) ([]places.PlaceDetails, error) {
+	results, ok, err := search.LoadResults(searchRecord.SearchID)
+	if err != nil || ok {
+		return results, err
+	}
+
+	supabaseClient, err := supabase.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Supabase client: %w", err)
+	}
+
+	csvData, err := supabaseClient.DownloadFile(searchRecord.FileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download CSV: %w", err)
+	}
+
+	results, err = export.ParseCSV(csvData)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		search.ClassifyPhone(&results[i], phone.DefaultCountry)
+	}
+	return results, nil
+}
+
+func GetSearchResults(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
 		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
 		return
 	}
 
-	var searches []models.Search
-	if err := database.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&searches).Error; err != nil {
-		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to fetch searches")
+	searchID := c.Param("searchId")
+
+	var searchRecord models.Search
+	if err := database.DB.Where("search_id = ? AND user_id = ?", searchID, userID).First(&searchRecord).Error; err != nil {
+		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Search not found")
 		return
 	}
 
-	response.SendGinResponse(c, http.StatusOK, searches, nil, "")
-}
+	params, err := pagination.Parse(c)
+	if err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
 
-func fetchPlacesForQuery(search string, city string, apiKey string, placeType string, uniquePlaces map[string]PlaceDetails, mutex *sync.Mutex) {
-	nextPageToken := ""
-	baseURL := "https://maps.googleapis.com/maps/api/place/textsearch/json"
-	maxPages := 3
+	query := search.ResultsQuery(searchID)
+	if minRating, err := strconv.ParseFloat(c.Query("min_rating"), 64); err == nil {
+		query = query.Where("places.rating >= ?", minRating)
+	}
+	if c.Query("has_phone") == "true" {
+		query = query.Where("places.formatted_phone_number <> ''")
+	}
+	if c.Query("has_website") == "true" {
+		query = query.Where("places.website <> ''")
+	}
+	if c.Query("only_mobile") == "true" {
+		query = query.Where("places.phone_type = ?", phone.TypeMobile)
+	}
+	if c.Query("has_email") == "true" {
+		query = query.Where("places.emails IS NOT NULL AND places.emails NOT IN ('', 'null', '[]')")
+	}
+	if name, ok := getParams.GetParams(c, "name"); ok {
+		query = query.Where("places.name ILIKE ?", pagination.Contains(name))
+	}
 
-	for pageCount := 0; pageCount < maxPages; pageCount++ {
-		query := fmt.Sprintf("%s in %s", search, city)
-		params := url.Values{}
-		params.Add("query", query)
-		params.Add("key", apiKey)
+	meta := pagination.Meta{Page: params.Page, Limit: params.Limit}
+	if err := query.Session(&gorm.Session{}).Count(&meta.Total).Error; err != nil {
+		log.Printf("Failed to count results for search %s: %v", searchID, err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to fetch search results")
+		return
+	}
 
-		if placeType != "" {
-			params.Add("type", placeType)
-		}
+	var rows []models.Place
+	if err := query.Select("places.*").Offset((params.Page - 1) * params.Limit).Limit(params.Limit).Find(&rows).Error; err != nil {
+		log.Printf("Failed to fetch results for search %s: %v", searchID, err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to fetch search results")
+		return
+	}
+	meta.HasMore = int64(params.Page*params.Limit) < meta.Total
 
-		if nextPageToken != "" {
-			params.Add("pagetoken", nextPageToken)
-		}
+	response.SendGinResponse(c, http.StatusOK, rows, meta, "")
+}
 
-		fullURL := fmt.Sprintf("%s?%s", baseURL, params.Encode())
+func GetUserSearches(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
 
-		resp, err := http.Get(fullURL)
-		if err != nil {
-			log.Printf("Failed to fetch data: %v", err)
-			break
-		}
-		defer resp.Body.Close()
+	params, err := pagination.Parse(c)
+	if err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
 
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			log.Printf("Failed to read response: %v", err)
-			break
-		}
+	searches, meta, err := pagination.Find[models.Search](database.DB.Where("user_id = ?", userID), params, pagination.Columns{
+		City:     "city",
+		Query:    "query",
+		Results:  "results",
+		Sortable: []string{"results", "query", "city"},
+	})
+	if errors.Is(err, pagination.ErrInvalidSort) {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to fetch searches: %v", err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to fetch searches")
+		return
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			log.Printf("API returned status %d: %s", resp.StatusCode, string(body[:min(200, len(body))]))
-			break
-		}
+	response.SendGinResponse(c, http.StatusOK, searches, meta, "")
+}
 
-		var placesResponse struct {
-			Results []struct {
-				PlaceID          string `json:"place_id"`
-				Name             string `json:"name"`
-				FormattedAddress string `json:"formatted_address"`
-			} `json:"results"`
-			NextPageToken string `json:"next_page_token"`
-			Status        string `json:"status"`
-			ErrorMessage  string `json:"error_message"`
-		}
+func GetSearchStatus(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
 
-		if err := json.Unmarshal(body, &placesResponse); err != nil {
-			log.Printf("Failed to parse JSON: %v", err)
-			break
-		}
+	searchID := c.Param("searchId")
+	if searchID == "" {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, "Search ID is required")
+		return
+	}
 
-		if placesResponse.Status != "OK" && placesResponse.Status != "ZERO_RESULTS" {
-			log.Printf("API error - Status: %s, Message: %s", placesResponse.Status, placesResponse.ErrorMessage)
-			break
+	var job models.SearchJob
+	if err := database.DB.Where("search_id = ? AND user_id = ?", searchID, userID).First(&job).Error; err == nil {
+		status := gin.H{
+			"search_id":       job.SearchID,
+			"status":          job.Status,
+			"regions_total":   job.RegionsTotal,
+			"regions_done":    job.RegionsDone,
+			"partial_results": job.PartialResults,
+			"total_results":   job.Results,
+			"filtered_out":    job.FilteredOut,
+			"excluded_seen":   job.ExcludedSeen,
+			"error":           job.Error,
+			"created_at":      job.CreatedAt,
+			"started_at":      job.StartedAt,
+			"finished_at":     job.FinishedAt,
 		}
-
-		log.Printf("Found %d results for query: %s", len(placesResponse.Results), query)
-
-		var detailsWg sync.WaitGroup
-
-		for _, result := range placesResponse.Results {
-			mutex.Lock()
-			if _, exists := uniquePlaces[result.PlaceID]; exists {
-				mutex.Unlock()
-				continue
-			}
-			uniquePlaces[result.PlaceID] = PlaceDetails{
-				Name:             result.Name,
-				FormattedAddress: result.FormattedAddress,
-				URL:              fmt.Sprintf("https://www.google.com/maps/place/?q=place_id:%s", result.PlaceID),
-			}
-			mutex.Unlock()
-
-			detailsWg.Add(1)
-			go func(placeID string, place PlaceDetails) {
-				defer detailsWg.Done()
-
-				detailsParams := url.Values{}
-				detailsParams.Add("place_id", placeID)
-				detailsParams.Add("fields", "formatted_phone_number,website")
-				detailsParams.Add("key", apiKey)
-
-				detailsURL := fmt.Sprintf("https://maps.googleapis.com/maps/api/place/details/json?%s", detailsParams.Encode())
-
-				detailsResp, err := http.Get(detailsURL)
-				if err != nil {
-					log.Printf("Failed to fetch details for place %s: %v", placeID, err)
-					return
-				}
-				defer detailsResp.Body.Close()
-
-				detailsBody, err := io.ReadAll(detailsResp.Body)
-				if err != nil {
-					log.Printf("Failed to read details response for place %s: %v", placeID, err)
-					return
-				}
-
-				if detailsResp.StatusCode != http.StatusOK {
-					log.Printf("Details API returned status %d for place %s", detailsResp.StatusCode, placeID)
-					return
-				}
-
-				var detailsResponse struct {
-					Result struct {
-						FormattedPhoneNumber string `json:"formatted_phone_number"`
-						Website              string `json:"website"`
-					} `json:"result"`
-					Status string `json:"status"`
-				}
-
-				if err := json.Unmarshal(detailsBody, &detailsResponse); err == nil && detailsResponse.Status == "OK" {
-					mutex.Lock()
-					if place, exists := uniquePlaces[placeID]; exists {
-						place.FormattedPhoneNumber = detailsResponse.Result.FormattedPhoneNumber
-						place.Website = detailsResponse.Result.Website
-						uniquePlaces[placeID] = place
-					}
-					mutex.Unlock()
-				}
-			}(result.PlaceID, uniquePlaces[result.PlaceID])
+		if job.Status == "done" {
+			status["download_url"] = fmt.Sprintf("/api/v1/consultancy/search/%s/csv", job.SearchID)
 		}
 
-		detailsWg.Wait()
-
-		if placesResponse.NextPageToken == "" {
-			break
-		}
+		response.SendGinResponse(c, http.StatusOK, status, nil, "")
+		return
+	}
 
-		nextPageToken = placesResponse.NextPageToken
-		time.Sleep(2 * time.Second)
+	// searches run synchronously have no job, they are done as soon as they exist
+	var searchRecord models.Search
+	if err := database.DB.Where("search_id = ? AND user_id = ?", searchID, userID).First(&searchRecord).Error; err != nil {
+		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Search not found")
+		return
 	}
+
+	response.SendGinResponse(c, http.StatusOK, gin.H{
+		"search_id":     searchRecord.SearchID,
+		"status":        "done",
+		"total_results": searchRecord.Results,
+		"created_at":    searchRecord.CreatedAt,
+		"download_url":  fmt.Sprintf("/api/v1/consultancy/search/%s/csv", searchRecord.SearchID),
+	}, nil, "")
 }
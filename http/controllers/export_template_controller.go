@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"medina-consultancy-api/database"
+	"medina-consultancy-api/models"
+	"medina-consultancy-api/pkg/export"
+	"medina-consultancy-api/pkg/response"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errExportTemplateNotFound = errors.New("export template not found")
+
+type ExportTemplateRequest struct {
+	Name      string   `json:"name" binding:"required"`
+	Columns   []string `json:"columns"`
+	Delimiter string   `json:"delimiter"`
+	Language  string   `json:"language"`
+	E164      bool     `json:"e164"`
+}
+
+func GetExportColumns(c *gin.Context) {
+	response.SendGinResponse(c, http.StatusOK, gin.H{
+		"columns":         export.ColumnIDs(),
+		"default_columns": export.DefaultColumns,
+		"delimiters":      []string{";", ",", "|", "tab"},
+		"languages":       []string{export.LanguagePortuguese, export.LanguageEnglish, export.LanguageSpanish},
+	}, nil, "")
+}
+
+func GetExportTemplates(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	var templates []models.ExportTemplate
+	if err := database.DB.Where("user_id = ?", userID).Order("name ASC").Find(&templates).Error; err != nil {
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to fetch export templates")
+		return
+	}
+
+	response.SendGinResponse(c, http.StatusOK, templates, nil, "")
+}
+
+func CreateExportTemplate(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	var req ExportTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+
+	opts, err := req.options()
+	if err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+
+	template := models.ExportTemplate{UserID: userID.(uint)}
+	applyExportTemplate(&template, req.Name, opts)
+
+	if err := database.DB.Create(&template).Error; err != nil {
+		sendExportTemplateError(c, err, "Failed to create export template")
+		return
+	}
+
+	response.SendGinResponse(c, http.StatusCreated, template, nil, "")
+}
+
+func UpdateExportTemplate(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	var template models.ExportTemplate
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&template).Error; err != nil {
+		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Export template not found")
+		return
+	}
+
+	var req ExportTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+
+	opts, err := req.options()
+	if err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+
+	applyExportTemplate(&template, req.Name, opts)
+
+	if err := database.DB.Save(&template).Error; err != nil {
+		sendExportTemplateError(c, err, "Failed to update export template")
+		return
+	}
+
+	response.SendGinResponse(c, http.StatusOK, template, nil, "")
+}
+
+func DeleteExportTemplate(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	result := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).Delete(&models.ExportTemplate{})
+	if result.Error != nil {
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to delete export template")
+		return
+	}
+	if result.RowsAffected == 0 {
+		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Export template not found")
+		return
+	}
+
+	response.SendGinResponse(c, http.StatusOK, gin.H{"deleted": true}, nil, "")
+}
+
+// sendExportTemplateError answers a failed template write, a conflict only when the user
+// already has a template with the name.
+func sendExportTemplateError(c *gin.Context, err error, message string) {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		response.SendGinResponse(c, http.StatusConflict, nil, nil, "An export template with this name already exists")
+		return
+	}
+	log.Printf("%s: %v", message, err)
+	response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, message)
+}
+
+func (r ExportTemplateRequest) options() (export.Options, error) {
+	return export.Options{
+		Columns:   r.Columns,
+		Delimiter: r.Delimiter,
+		Language:  r.Language,
+		E164:      r.E164,
+	}.Normalize()
+}
+
+func applyExportTemplate(template *models.ExportTemplate, name string, opts export.Options) {
+	template.Name = strings.TrimSpace(name)
+	template.Columns = opts.Columns
+	template.Delimiter = opts.Delimiter
+	template.Language = opts.Language
+	template.E164 = opts.E164
+}
+
+// exportOptionsFromQuery starts from the saved template given in ?template= (ID or name)
+// and applies the columns, delimiter, lang and e164 query parameters on top of it.
+func exportOptionsFromQuery(c *gin.Context, userID interface{}) (export.Options, error) {
+	opts := export.DefaultOptions()
+
+	if name := c.Query("template"); name != "" {
+		var template models.ExportTemplate
+		query := database.DB.Where("user_id = ?", userID)
+		if id, err := strconv.ParseUint(name, 10, 64); err == nil {
+			query = query.Where("id = ?", id)
+		} else {
+			query = query.Where("name = ?", name)
+		}
+
+		if err := query.First(&template).Error; err != nil {
+			return opts, errExportTemplateNotFound
+		}
+
+		opts = export.Options{
+			Columns:   template.Columns,
+			Delimiter: template.Delimiter,
+			Language:  template.Language,
+			E164:      template.E164,
+		}
+	}
+
+	if columns := c.Query("columns"); columns != "" {
+		opts.Columns = strings.Split(columns, ",")
+	}
+	if delimiter := c.Query("delimiter"); delimiter != "" {
+		opts.Delimiter = delimiter
+	}
+	if language := c.Query("lang"); language != "" {
+		opts.Language = language
+	}
+	if e164 := c.Query("e164"); e164 != "" {
+		opts.E164 = e164 == "true"
+	}
+
+	return opts.Normalize()
+}
+
+// hasExportOptions tells whether the request customizes the export at all.
+func hasExportOptions(c *gin.Context) bool {
+	for _, key := range []string{"template", "columns", "delimiter", "lang", "e164"} {
+		if c.Query(key) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// sendExportOptionsError answers the errors returned by exportOptionsFromQuery.
+func sendExportOptionsError(c *gin.Context, err error) {
+	if errors.Is(err, errExportTemplateNotFound) {
+		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Export template not found")
+		return
+	}
+	response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+}
@@ -1,20 +1,24 @@
 package controllers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"medina-consultancy-api/database"
 	"medina-consultancy-api/models"
+	"medina-consultancy-api/pkg/billing"
+	"medina-consultancy-api/pkg/export"
+	"medina-consultancy-api/pkg/pagination"
+	"medina-consultancy-api/pkg/places"
 	"medina-consultancy-api/pkg/response"
-	"medina-consultancy-api/pkg/supabase"
+	"medina-consultancy-api/pkg/search"
 	"net/http"
-	"os"
-	"strings"
-	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 func IntegrationSearch(c *gin.Context) {
@@ -26,119 +30,166 @@ func IntegrationSearch(c *gin.Context) {
 
 	subscriptionID, _ := c.Get("subscriptionID")
 
-	var cityReq CityRequest
+	var cityReq search.CityRequest
 	if err := c.ShouldBindJSON(&cityReq); err != nil {
 		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, "Invalid request body")
 		return
 	}
 
-	if cityReq.Search == "" || cityReq.City == "" {
-		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, "Search and city fields are required")
+	if err := cityReq.Validate(); err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
 		return
 	}
 
-	apiKey := os.Getenv("GOOGLE_PLACES_API_KEY")
-	if apiKey == "" {
-		log.Printf("API key is missing")
-		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "API key is missing")
-		return
+	// ?template= (or columns, lang, e164) adds the results formatted like an export
+	var exportOpts *export.Options
+	if hasExportOptions(c) {
+		opts, err := exportOptionsFromQuery(c, userID)
+		if err != nil {
+			sendExportOptionsError(c, err)
+			return
+		}
+		exportOpts = &opts
 	}
 
-	uniquePlaces := make(map[string]PlaceDetails)
-	var mutex sync.Mutex
-	var wg sync.WaitGroup
-
-	regions := []string{"", "centro", "norte", "sul", "leste", "oeste"}
-
-	for _, region := range regions {
-		wg.Add(1)
-		go func(region string) {
-			defer wg.Done()
-
-			searchQuery := cityReq.Search
-			cityQuery := cityReq.City
-
-			if len(cityReq.Keywords) > 0 {
-				searchQuery = fmt.Sprintf("%s %s", searchQuery, strings.Join(cityReq.Keywords, " "))
-			}
-
-			if region != "" {
-				cityQuery = fmt.Sprintf("%s %s", cityReq.City, region)
-			}
+	// the results of an identical query were all delivered already
+	if !cityReq.ForceRefresh && !cityReq.OnlyNew {
+		original, err := search.FindReusableIntegrationQuery(subscriptionID.(uint), cityReq.Fingerprint())
+		if err != nil {
+			log.Printf("Failed to look up reusable integration query: %v", err)
+		} else if original != nil {
+			reuseIntegrationQuery(c, original, exportOpts)
+			return
+		}
+	}
 
-			fetchPlacesForQuery(searchQuery, cityQuery, apiKey, cityReq.PlaceType, uniquePlaces, &mutex)
-		}(region)
+	provider, err := places.NewProviderFromEnv()
+	if err != nil {
+		log.Printf("Failed to configure place provider: %v", err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Place provider is not configured")
+		return
 	}
 
-	wg.Wait()
+	// stops calling the provider when the client disconnects or the API timeout is near
+	ctx, cancel := context.WithTimeout(c.Request.Context(), search.SyncTimeout())
+	defer cancel()
 
-	var search []PlaceDetails
-	for _, place := range uniquePlaces {
-		search = append(search, place)
+	result, err := search.Run(ctx, provider, cityReq, nil)
+	if err != nil {
+		log.Printf("Search failed: %v", err)
+		if errors.Is(err, context.DeadlineExceeded) {
+			response.SendGinResponse(c, http.StatusGatewayTimeout, nil, nil, "Search took too long, try a quicker depth")
+			return
+		}
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Search failed")
+		return
 	}
+	owner := search.Owner{UserID: userID.(uint), SubscriptionID: subscriptionID.(uint)}
+	if cityReq.OnlyNew {
+		if err := search.ExcludeSeen(result, provider.Name(), owner); err != nil {
+			log.Printf("Failed to exclude seen places: %v", err)
+			response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Search failed")
+			return
+		}
+	}
+	results := result.Places
 
-	log.Printf("Integration search - Total unique results: %d", len(search))
+	log.Printf("Integration search - Total unique results: %d", len(results))
 
 	searchID := uuid.New().String()
-	fileName := fmt.Sprintf("searches/%s.csv", searchID)
 
-	csvData, err := generateCSV(search)
+	_, bucketURL, err := search.UploadCSV(searchID, results)
 	if err != nil {
-		log.Printf("Failed to generate CSV: %v", err)
-		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to generate CSV")
+		log.Printf("Failed to store search results: %v", err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to save search results")
 		return
 	}
 
-	supabaseClient, err := supabase.NewClient()
+	integrationQuery := search.IntegrationQueryRecord(subscriptionID.(uint), userID.(uint), searchID, cityReq, len(results), bucketURL)
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(integrationQuery).Error; err != nil {
+			return err
+		}
+		if err := search.SaveResults(tx, provider.Name(), searchID, results); err != nil {
+			return err
+		}
+		return search.MarkSeen(tx, owner, searchID)
+	})
 	if err != nil {
-		log.Printf("Failed to create Supabase client: %v", err)
-		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to initialize storage")
+		log.Printf("Failed to save integration query record: %v", err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to save query record")
 		return
 	}
 
-	bucketURL, err := supabaseClient.UploadFile(fileName, csvData, "text/csv")
+	sendIntegrationResults(c, integrationQuery, results, exportOpts, result.Meta)
+}
+
+// reuseIntegrationQuery answers with the results of a recent identical query, billed at
+// the re-use price.
+func reuseIntegrationQuery(c *gin.Context, original *models.IntegrationQuery, exportOpts *export.Options) {
+	results, _, err := search.LoadResults(original.SearchID)
 	if err != nil {
-		log.Printf("Failed to upload CSV to Supabase: %v", err)
-		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to save search results")
+		log.Printf("Failed to load results of query %s for re-use: %v", original.SearchID, err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to load search results")
 		return
 	}
 
-	billingMonth := time.Now().Format("2006-01")
-	integrationQuery := models.IntegrationQuery{
-		SubscriptionID: subscriptionID.(uint),
-		UserID:         userID.(uint),
-		SearchID:       searchID,
-		Query:          cityReq.Search,
-		City:           cityReq.City,
-		Results:        len(search),
-		BucketURL:      bucketURL,
-		BillingMonth:   billingMonth,
-	}
-
-	if err := database.DB.Create(&integrationQuery).Error; err != nil {
-		log.Printf("Failed to save integration query record: %v", err)
+	integrationQuery := search.ReusedIntegrationQueryRecord(original)
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(integrationQuery).Error; err != nil {
+			return err
+		}
+		return search.CopyResults(tx, original.SearchID, integrationQuery.SearchID)
+	})
+	if err != nil {
+		log.Printf("Failed to save re-used integration query: %v", err)
 		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to save query record")
 		return
 	}
 
+	log.Printf("Integration query %s re-used as %s", original.SearchID, integrationQuery.SearchID)
+
+	sendIntegrationResults(c, integrationQuery, results, exportOpts, nil)
+}
+
+func sendIntegrationResults(c *gin.Context, integrationQuery *models.IntegrationQuery, results []places.PlaceDetails, exportOpts *export.Options, meta interface{}) {
 	// get current month query count for billing info
-	var queryCount int64
-	database.DB.Model(&models.IntegrationQuery{}).
-		Where("subscription_id = ? AND billing_month = ?", subscriptionID, billingMonth).
-		Count(&queryCount)
+	queryCount, reusedCount, err := billing.MonthlyQueryCounts(integrationQuery.SubscriptionID, integrationQuery.BillingMonth)
+	if err != nil {
+		log.Printf("Failed to count integration queries: %v", err)
+	}
 
 	unitPrice := CalculateUnitPrice(int(queryCount))
 
-	response.SendGinResponse(c, http.StatusOK, gin.H{
-		"search_id":     searchID,
-		"results":       search,
-		"total_results": len(search),
-		"download_url":  bucketURL,
+	data := gin.H{
+		"search_id":     integrationQuery.SearchID,
+		"results":       results,
+		"total_results": len(results),
+		"download_url":  integrationQuery.BucketURL,
 		"billing": gin.H{
 			"queries_this_month": queryCount,
+			"reused_this_month":  reusedCount,
 			"current_tier_price": fmt.Sprintf("%.2f", unitPrice),
+			"reuse_price":        fmt.Sprintf("%.2f", billing.ReusePrice()),
+			"reused":             integrationQuery.Reused,
 		},
-	}, nil, "")
+	}
+	if integrationQuery.Reused {
+		data["reused_from"] = integrationQuery.ReusedFrom
+	}
+
+	if exportOpts != nil {
+		records, err := export.Records(results, *exportOpts)
+		if err != nil {
+			log.Printf("Failed to format integration results: %v", err)
+		} else {
+			data["records"] = records
+		}
+	}
+
+	response.SendGinResponse(c, http.StatusOK, data, meta, "")
 }
 
 func GetUsage(c *gin.Context) {
@@ -149,13 +200,13 @@ func GetUsage(c *gin.Context) {
 	}
 
 	billingMonth := time.Now().Format("2006-01")
-	var queryCount int64
-	database.DB.Model(&models.IntegrationQuery{}).
-		Where("subscription_id = ? AND billing_month = ?", subscriptionID, billingMonth).
-		Count(&queryCount)
+	queryCount, reusedCount, err := billing.MonthlyQueryCounts(subscriptionID.(uint), billingMonth)
+	if err != nil {
+		log.Printf("Failed to count integration queries: %v", err)
+	}
 
 	unitPrice := CalculateUnitPrice(int(queryCount))
-	estimatedTotal := unitPrice * float64(queryCount)
+	estimatedTotal := unitPrice*float64(queryCount) + billing.ReusePrice()*float64(reusedCount)
 
 	tier := "0-99"
 	if queryCount >= 200 {
@@ -167,8 +218,42 @@ func GetUsage(c *gin.Context) {
 	response.SendGinResponse(c, http.StatusOK, gin.H{
 		"billing_month":      billingMonth,
 		"queries_this_month": queryCount,
+		"reused_this_month":  reusedCount,
 		"current_tier":       tier,
 		"unit_price":         fmt.Sprintf("%.2f", unitPrice),
+		"reuse_price":        fmt.Sprintf("%.2f", billing.ReusePrice()),
 		"estimated_total":    fmt.Sprintf("%.2f", estimatedTotal),
 	}, nil, "")
 }
+
+func GetIntegrationQueries(c *gin.Context) {
+	subscriptionID, exists := c.Get("subscriptionID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "Subscription not found")
+		return
+	}
+
+	params, err := pagination.Parse(c)
+	if err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+
+	queries, meta, err := pagination.Find[models.IntegrationQuery](database.DB.Where("subscription_id = ?", subscriptionID), params, pagination.Columns{
+		City:     "city",
+		Query:    "query",
+		Results:  "results",
+		Sortable: []string{"results", "billing_month"},
+	})
+	if errors.Is(err, pagination.ErrInvalidSort) {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to fetch integration queries: %v", err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to fetch integration queries")
+		return
+	}
+
+	response.SendGinResponse(c, http.StatusOK, queries, meta, "")
+}
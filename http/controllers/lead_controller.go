@@ -0,0 +1,682 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"medina-consultancy-api/database"
+	"medina-consultancy-api/models"
+	"medina-consultancy-api/pkg/export"
+	"medina-consultancy-api/pkg/pagination"
+	"medina-consultancy-api/pkg/response"
+	"medina-consultancy-api/pkg/search"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var leadStatuses = []string{"new", "contacted", "qualified", "won", "lost"}
+
+type AddLeadsRequest struct {
+	SearchID string   `json:"search_id" binding:"required"`
+	PlaceIDs []string `json:"place_ids"` // provider place IDs, every result of the search when empty
+	ListID   uint     `json:"list_id"`
+	ListName string   `json:"list_name"` // created when the user has no list with this name
+	Tags     []string `json:"tags"`
+	Status   string   `json:"status"` // for new leads only, existing leads keep their status
+}
+
+type UpdateLeadRequest struct {
+	Status *string   `json:"status"`
+	Tags   *[]string `json:"tags"` // replaces the tags
+}
+
+type LeadNoteRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+type LeadListRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type LeadListItemsRequest struct {
+	LeadIDs []uint `json:"lead_ids" binding:"required"`
+}
+
+func validLeadStatus(status string) error {
+	if !slices.Contains(leadStatuses, status) {
+		return fmt.Errorf("status must be one of: %s", strings.Join(leadStatuses, ", "))
+	}
+	return nil
+}
+
+// normalizeTags trims the tags and drops empty and repeated ones.
+func normalizeTags(tags []string) []string {
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
+func mergeUnique(values []string, more ...string) []string {
+	for _, value := range more {
+		if !slices.Contains(values, value) {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// jsonContains matches leads whose JSON array column holds value.
+func jsonContains(column string, value string) (string, string) {
+	encoded, _ := json.Marshal([]string{value})
+	return column + "::jsonb @> ?::jsonb", string(encoded)
+}
+
+// leadFilters applies the status, query (place name), tag, list_id and search_id filters
+// shared by the list and the export of leads.
+func leadFilters(c *gin.Context, query *gorm.DB) (*gorm.DB, error) {
+	if status := c.Query("status"); status != "" {
+		if err := validLeadStatus(status); err != nil {
+			return nil, err
+		}
+		query = query.Where("status = ?", status)
+	}
+	if name := c.Query("query"); name != "" {
+		query = query.Where("place_id IN (SELECT id FROM places WHERE name ILIKE ?)", pagination.Contains(name))
+	}
+	if tag := c.Query("tag"); tag != "" {
+		query = query.Where(jsonContains("tags", tag))
+	}
+	if searchID := c.Query("search_id"); searchID != "" {
+		query = query.Where(jsonContains("search_ids", searchID))
+	}
+	if listID := c.Query("list_id"); listID != "" {
+		query = query.Where("id IN (SELECT lead_id FROM lead_list_items WHERE lead_list_id = ?)", listID)
+	}
+	return query, nil
+}
+
+func GetLeads(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	params, err := pagination.Parse(c)
+	if err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+
+	query, err := leadFilters(c, database.DB.Where("user_id = ?", userID))
+	if err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+
+	leads, meta, err := pagination.Find[models.Lead](query.Preload("Place").Preload("Lists"), params, pagination.Columns{
+		Sortable: []string{"status", "updated_at", "status_changed_at"},
+	})
+	if errors.Is(err, pagination.ErrInvalidSort) {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to fetch leads: %v", err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to fetch leads")
+		return
+	}
+
+	response.SendGinResponse(c, http.StatusOK, leads, meta, "")
+}
+
+// AddLeads turns results of a search into leads. Places that already are leads of the user
+// are not duplicated, they get the search and tags added instead.
+func AddLeads(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	var req AddLeadsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, "Invalid request body")
+		return
+	}
+	if req.Status == "" {
+		req.Status = "new"
+	}
+	if err := validLeadStatus(req.Status); err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+	tags := normalizeTags(req.Tags)
+
+	var searchRecord models.Search
+	if err := database.DB.Where("search_id = ? AND user_id = ?", req.SearchID, userID).First(&searchRecord).Error; err != nil {
+		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Search not found")
+		return
+	}
+
+	query := search.ResultsQuery(req.SearchID)
+	if len(req.PlaceIDs) > 0 {
+		query = query.Where("places.external_id IN ?", req.PlaceIDs)
+	}
+	var placeIDs []uint
+	if err := query.Pluck("places.id", &placeIDs).Error; err != nil {
+		log.Printf("Failed to load results of search %s: %v", req.SearchID, err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to read search results")
+		return
+	}
+	if len(placeIDs) == 0 {
+		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "No stored results found for this search")
+		return
+	}
+
+	var list *models.LeadList
+	switch {
+	case req.ListID != 0:
+		list = &models.LeadList{}
+		if err := database.DB.Where("id = ? AND user_id = ?", req.ListID, userID).First(list).Error; err != nil {
+			response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Lead list not found")
+			return
+		}
+	case strings.TrimSpace(req.ListName) != "":
+		list = &models.LeadList{}
+		err := database.DB.Where(models.LeadList{UserID: userID.(uint), Name: strings.TrimSpace(req.ListName)}).FirstOrCreate(list).Error
+		if err != nil {
+			log.Printf("Failed to create lead list %q: %v", req.ListName, err)
+			response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to create lead list")
+			return
+		}
+	}
+
+	created, updated := 0, 0
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var existing []models.Lead
+		if err := tx.Where("user_id = ? AND place_id IN ?", userID, placeIDs).Find(&existing).Error; err != nil {
+			return err
+		}
+
+		known := make(map[uint]bool, len(existing))
+		leadIDs := make([]uint, 0, len(placeIDs))
+		for _, lead := range existing {
+			known[lead.PlaceID] = true
+			leadIDs = append(leadIDs, lead.ID)
+
+			searchIDs := mergeUnique(lead.SearchIDs, req.SearchID)
+			leadTags := mergeUnique(lead.Tags, tags...)
+			if len(searchIDs) == len(lead.SearchIDs) && len(leadTags) == len(lead.Tags) {
+				continue
+			}
+			if err := tx.Model(&lead).Updates(models.Lead{SearchIDs: searchIDs, Tags: leadTags}).Error; err != nil {
+				return err
+			}
+			updated++
+		}
+
+		now := time.Now()
+		var leads []models.Lead
+		for _, placeID := range placeIDs {
+			if known[placeID] {
+				continue
+			}
+			leads = append(leads, models.Lead{
+				UserID:          userID.(uint),
+				PlaceID:         placeID,
+				Status:          req.Status,
+				StatusChangedAt: &now,
+				Tags:            tags,
+				SearchIDs:       []string{req.SearchID},
+			})
+		}
+		if len(leads) > 0 {
+			if err := tx.CreateInBatches(&leads, 500).Error; err != nil {
+				return err
+			}
+		}
+		for _, lead := range leads {
+			leadIDs = append(leadIDs, lead.ID)
+		}
+		created = len(leads)
+
+		if list == nil {
+			return nil
+		}
+		return addToLeadList(tx, list.ID, leadIDs)
+	})
+	if err != nil {
+		log.Printf("Failed to add leads from search %s: %v", req.SearchID, err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to add leads")
+		return
+	}
+
+	response.SendGinResponse(c, http.StatusOK, gin.H{"created": created, "updated": updated, "list": list}, nil, "")
+}
+
+func addToLeadList(tx *gorm.DB, listID uint, leadIDs []uint) error {
+	if len(leadIDs) == 0 {
+		return nil
+	}
+
+	items := make([]map[string]interface{}, len(leadIDs))
+	for i, leadID := range leadIDs {
+		items[i] = map[string]interface{}{"lead_list_id": listID, "lead_id": leadID}
+	}
+	return tx.Table("lead_list_items").Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(items, 500).Error
+}
+
+func GetLead(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	var lead models.Lead
+	err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).
+		Preload("Place").
+		Preload("Lists").
+		Preload("Notes", func(db *gorm.DB) *gorm.DB { return db.Order("created_at DESC") }).
+		First(&lead).Error
+	if err != nil {
+		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Lead not found")
+		return
+	}
+
+	response.SendGinResponse(c, http.StatusOK, lead, nil, "")
+}
+
+func UpdateLead(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	var lead models.Lead
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&lead).Error; err != nil {
+		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Lead not found")
+		return
+	}
+
+	var req UpdateLeadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, "Invalid request body")
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.Status != nil && *req.Status != lead.Status {
+		if err := validLeadStatus(*req.Status); err != nil {
+			response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+			return
+		}
+		updates["status"] = *req.Status
+		updates["status_changed_at"] = time.Now()
+	}
+	if req.Tags != nil {
+		// the serializer is only applied to struct fields
+		tags, _ := json.Marshal(normalizeTags(*req.Tags))
+		updates["tags"] = string(tags)
+	}
+
+	if len(updates) > 0 {
+		if err := database.DB.Model(&lead).Updates(updates).Error; err != nil {
+			log.Printf("Failed to update lead %d: %v", lead.ID, err)
+			response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to update lead")
+			return
+		}
+	}
+
+	database.DB.Preload("Place").Preload("Lists").First(&lead, lead.ID)
+	response.SendGinResponse(c, http.StatusOK, lead, nil, "")
+}
+
+func DeleteLead(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	var lead models.Lead
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&lead).Error; err != nil {
+		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Lead not found")
+		return
+	}
+
+	// also removes the lead from its lists and deletes its notes
+	if err := database.DB.Select("Lists", "Notes").Delete(&lead).Error; err != nil {
+		log.Printf("Failed to delete lead %d: %v", lead.ID, err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to delete lead")
+		return
+	}
+
+	response.SendGinResponse(c, http.StatusOK, gin.H{"deleted": true}, nil, "")
+}
+
+func AddLeadNote(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	var lead models.Lead
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&lead).Error; err != nil {
+		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Lead not found")
+		return
+	}
+
+	var req LeadNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Body) == "" {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, "Invalid request body")
+		return
+	}
+
+	note := models.LeadNote{LeadID: lead.ID, UserID: lead.UserID, Body: strings.TrimSpace(req.Body)}
+	if err := database.DB.Create(&note).Error; err != nil {
+		log.Printf("Failed to add note to lead %d: %v", lead.ID, err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to add note")
+		return
+	}
+
+	// notes count as activity on the lead
+	database.DB.Model(&lead).Update("updated_at", time.Now())
+
+	response.SendGinResponse(c, http.StatusCreated, note, nil, "")
+}
+
+func DeleteLeadNote(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	result := database.DB.Where("id = ? AND lead_id = ? AND user_id = ?", c.Param("noteId"), c.Param("id"), userID).Delete(&models.LeadNote{})
+	if result.Error != nil {
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to delete note")
+		return
+	}
+	if result.RowsAffected == 0 {
+		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Note not found")
+		return
+	}
+
+	response.SendGinResponse(c, http.StatusOK, gin.H{"deleted": true}, nil, "")
+}
+
+// ExportLeads downloads the leads matching the list filters with ?format=csv|xlsx|json and
+// the same column options as search exports.
+func ExportLeads(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	formatName := c.DefaultQuery("format", "csv")
+	format, err := export.LookupFormat(formatName)
+	if err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+
+	opts, err := exportOptionsFromQuery(c, userID)
+	if err != nil {
+		sendExportOptionsError(c, err)
+		return
+	}
+
+	query, err := leadFilters(c, database.DB.Where("user_id = ?", userID))
+	if err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+
+	var leads []models.Lead
+	err = query.Preload("Place").
+		Preload("Lists").
+		Preload("Notes", func(db *gorm.DB) *gorm.DB { return db.Order("created_at DESC") }).
+		Order("id ASC").
+		Find(&leads).Error
+	if err != nil {
+		log.Printf("Failed to fetch leads for export: %v", err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to fetch leads")
+		return
+	}
+
+	rows := make([]models.Place, len(leads))
+	for i, lead := range leads {
+		rows[i] = lead.Place
+	}
+	details := search.PlaceDetailsFromRows(rows)
+
+	exported := make([]export.Lead, len(leads))
+	for i, lead := range leads {
+		exported[i] = export.Lead{
+			PlaceDetails: details[i],
+			Status:       lead.Status,
+			Tags:         lead.Tags,
+			Lists:        []string{},
+		}
+		for _, list := range lead.Lists {
+			exported[i].Lists = append(exported[i].Lists, list.Name)
+		}
+		if len(lead.Notes) > 0 {
+			exported[i].LastNote = lead.Notes[0].Body
+		}
+	}
+
+	data, err := export.Leads(exported, format, opts)
+	if err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=leads.%s", format.Extension))
+	c.Header("Content-Type", format.ContentType)
+	c.Data(http.StatusOK, format.ContentType, data)
+}
+
+func GetLeadLists(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	var lists []models.LeadList
+	err := database.DB.Select("lead_lists.*, (SELECT COUNT(*) FROM lead_list_items WHERE lead_list_items.lead_list_id = lead_lists.id) AS lead_count").
+		Where("user_id = ?", userID).
+		Order("name ASC").
+		Find(&lists).Error
+	if err != nil {
+		log.Printf("Failed to fetch lead lists: %v", err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to fetch lead lists")
+		return
+	}
+
+	response.SendGinResponse(c, http.StatusOK, lists, nil, "")
+}
+
+func CreateLeadList(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	var req LeadListRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, "Invalid request body")
+		return
+	}
+
+	list := models.LeadList{UserID: userID.(uint), Name: strings.TrimSpace(req.Name)}
+
+	var count int64
+	database.DB.Model(&models.LeadList{}).Where("user_id = ? AND name = ?", list.UserID, list.Name).Count(&count)
+	if count > 0 {
+		response.SendGinResponse(c, http.StatusConflict, nil, nil, "A lead list with this name already exists")
+		return
+	}
+
+	if err := database.DB.Create(&list).Error; err != nil {
+		sendLeadListError(c, err, "Failed to create lead list")
+		return
+	}
+
+	response.SendGinResponse(c, http.StatusCreated, list, nil, "")
+}
+
+func UpdateLeadList(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	var list models.LeadList
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("listId"), userID).First(&list).Error; err != nil {
+		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Lead list not found")
+		return
+	}
+
+	var req LeadListRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, "Invalid request body")
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+
+	var count int64
+	database.DB.Model(&models.LeadList{}).Where("user_id = ? AND name = ? AND id <> ?", userID, name, list.ID).Count(&count)
+	if count > 0 {
+		response.SendGinResponse(c, http.StatusConflict, nil, nil, "A lead list with this name already exists")
+		return
+	}
+
+	if err := database.DB.Model(&list).Update("name", name).Error; err != nil {
+		sendLeadListError(c, err, "Failed to update lead list")
+		return
+	}
+	list.Name = name
+
+	response.SendGinResponse(c, http.StatusOK, list, nil, "")
+}
+
+// sendLeadListError answers a failed list write, a conflict when a concurrent request took
+// the name after the check.
+func sendLeadListError(c *gin.Context, err error, message string) {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		response.SendGinResponse(c, http.StatusConflict, nil, nil, "A lead list with this name already exists")
+		return
+	}
+	log.Printf("%s: %v", message, err)
+	response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, message)
+}
+
+// DeleteLeadList removes the list only, its leads are kept.
+func DeleteLeadList(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	var list models.LeadList
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("listId"), userID).First(&list).Error; err != nil {
+		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Lead list not found")
+		return
+	}
+
+	if err := database.DB.Select("Leads").Delete(&list).Error; err != nil {
+		log.Printf("Failed to delete lead list %d: %v", list.ID, err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to delete lead list")
+		return
+	}
+
+	response.SendGinResponse(c, http.StatusOK, gin.H{"deleted": true}, nil, "")
+}
+
+func AddLeadsToList(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	var list models.LeadList
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("listId"), userID).First(&list).Error; err != nil {
+		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Lead list not found")
+		return
+	}
+
+	var req LeadListItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, "Invalid request body")
+		return
+	}
+
+	// ignore IDs of leads from other users
+	var leadIDs []uint
+	if err := database.DB.Model(&models.Lead{}).Where("user_id = ? AND id IN ?", userID, req.LeadIDs).Pluck("id", &leadIDs).Error; err != nil {
+		log.Printf("Failed to fetch leads for list %d: %v", list.ID, err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to add leads to list")
+		return
+	}
+	if len(leadIDs) == 0 {
+		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Leads not found")
+		return
+	}
+
+	if err := addToLeadList(database.DB, list.ID, leadIDs); err != nil {
+		log.Printf("Failed to add leads to list %d: %v", list.ID, err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to add leads to list")
+		return
+	}
+
+	response.SendGinResponse(c, http.StatusOK, gin.H{"added": len(leadIDs)}, nil, "")
+}
+
+func RemoveLeadFromList(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	var list models.LeadList
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("listId"), userID).First(&list).Error; err != nil {
+		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Lead list not found")
+		return
+	}
+
+	result := database.DB.Exec("DELETE FROM lead_list_items WHERE lead_list_id = ? AND lead_id = ?", list.ID, c.Param("id"))
+	if result.Error != nil {
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to remove lead from list")
+		return
+	}
+	if result.RowsAffected == 0 {
+		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Lead is not in this list")
+		return
+	}
+
+	response.SendGinResponse(c, http.StatusOK, gin.H{"removed": true}, nil, "")
+}
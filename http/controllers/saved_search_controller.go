@@ -0,0 +1,297 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"medina-consultancy-api/database"
+	"medina-consultancy-api/models"
+	"medina-consultancy-api/pkg/export"
+	"medina-consultancy-api/pkg/pagination"
+	"medina-consultancy-api/pkg/response"
+	"medina-consultancy-api/pkg/search"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SavedSearchRequest struct {
+	Name     string             `json:"name" binding:"required"`
+	Schedule string             `json:"schedule" binding:"required"` // daily, weekly or monthly
+	Request  search.CityRequest `json:"request"`
+	Active   *bool              `json:"active"` // defaults to true
+}
+
+func (r SavedSearchRequest) validate() error {
+	if !search.ValidSchedule(r.Schedule) {
+		return fmt.Errorf("schedule must be daily, weekly or monthly")
+	}
+	// every run would only find what the previous one missed, diffs already list new places
+	if r.Request.OnlyNew {
+		return fmt.Errorf("only_new is not supported by saved searches, use the new places of each run instead")
+	}
+	return r.Request.Validate()
+}
+
+// savedSearchScope limits saved searches to the user, and to the subscription when called
+// from the integration API.
+func savedSearchScope(c *gin.Context, userID interface{}) *gorm.DB {
+	query := database.DB.Where("user_id = ?", userID)
+	if subscriptionID, ok := c.Get("subscriptionID"); ok {
+		return query.Where("subscription_id = ?", subscriptionID)
+	}
+	return query.Where("subscription_id IS NULL")
+}
+
+func GetSavedSearches(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	params, err := pagination.Parse(c)
+	if err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+
+	savedSearches, meta, err := pagination.Find[models.SavedSearch](savedSearchScope(c, userID), params, pagination.Columns{
+		Query:    "name",
+		Sortable: []string{"name", "next_run_at", "last_run_at"},
+	})
+	if errors.Is(err, pagination.ErrInvalidSort) {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to fetch saved searches: %v", err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to fetch saved searches")
+		return
+	}
+
+	response.SendGinResponse(c, http.StatusOK, savedSearches, meta, "")
+}
+
+// CreateSavedSearch stores the search and schedules its first run, the baseline later runs
+// are compared with, for the next scheduler pass.
+func CreateSavedSearch(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	var req SavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, "Invalid request body")
+		return
+	}
+	if err := req.validate(); err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+
+	request, err := json.Marshal(req.Request)
+	if err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, "Invalid search request")
+		return
+	}
+
+	savedSearch := models.SavedSearch{
+		UserID:    userID.(uint),
+		Name:      req.Name,
+		Request:   request,
+		Schedule:  req.Schedule,
+		Active:    req.Active == nil || *req.Active,
+		NextRunAt: time.Now(),
+	}
+	if subscriptionID, ok := c.Get("subscriptionID"); ok {
+		id := subscriptionID.(uint)
+		savedSearch.SubscriptionID = &id
+	}
+
+	if err := database.DB.Create(&savedSearch).Error; err != nil {
+		log.Printf("Failed to create saved search: %v", err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to create saved search")
+		return
+	}
+
+	// gorm skips zero values that have a default on create
+	if !savedSearch.Active {
+		database.DB.Model(&savedSearch).Update("active", false)
+	}
+
+	response.SendGinResponse(c, http.StatusCreated, savedSearch, nil, "")
+}
+
+func UpdateSavedSearch(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	var savedSearch models.SavedSearch
+	if err := savedSearchScope(c, userID).Where("id = ?", c.Param("id")).First(&savedSearch).Error; err != nil {
+		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Saved search not found")
+		return
+	}
+
+	var req SavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, "Invalid request body")
+		return
+	}
+	if err := req.validate(); err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+
+	request, err := json.Marshal(req.Request)
+	if err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, "Invalid search request")
+		return
+	}
+
+	// a new schedule counts from the last run
+	if req.Schedule != savedSearch.Schedule && savedSearch.LastRunAt != nil {
+		savedSearch.NextRunAt = search.NextRun(req.Schedule, *savedSearch.LastRunAt)
+	}
+
+	savedSearch.Name = req.Name
+	savedSearch.Request = request
+	savedSearch.Schedule = req.Schedule
+	if req.Active != nil {
+		savedSearch.Active = *req.Active
+	}
+
+	if err := database.DB.Save(&savedSearch).Error; err != nil {
+		log.Printf("Failed to update saved search %d: %v", savedSearch.ID, err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to update saved search")
+		return
+	}
+
+	response.SendGinResponse(c, http.StatusOK, savedSearch, nil, "")
+}
+
+func DeleteSavedSearch(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	result := savedSearchScope(c, userID).Where("id = ?", c.Param("id")).Delete(&models.SavedSearch{})
+	if result.Error != nil {
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to delete saved search")
+		return
+	}
+	if result.RowsAffected == 0 {
+		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Saved search not found")
+		return
+	}
+
+	response.SendGinResponse(c, http.StatusOK, gin.H{"deleted": true}, nil, "")
+}
+
+func GetSavedSearchRuns(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	var savedSearch models.SavedSearch
+	if err := savedSearchScope(c, userID).Where("id = ?", c.Param("id")).First(&savedSearch).Error; err != nil {
+		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Saved search not found")
+		return
+	}
+
+	params, err := pagination.Parse(c)
+	if err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+
+	runs, meta, err := pagination.Find[models.SavedSearchRun](database.DB.Where("saved_search_id = ?", savedSearch.ID), params, pagination.Columns{
+		Results:  "results",
+		Status:   "status",
+		Sortable: []string{"results", "new_places", "closed_places", "changed_places"},
+	})
+	if errors.Is(err, pagination.ErrInvalidSort) {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to fetch runs of saved search %d: %v", savedSearch.ID, err)
+		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to fetch saved search runs")
+		return
+	}
+
+	response.SendGinResponse(c, http.StatusOK, runs, meta, "")
+}
+
+// GetSavedSearchDiff returns the changes found by a run, :runId can be "latest" for the
+// last successful one. With ?format=csv|xlsx|json the diff is downloaded as a file.
+func GetSavedSearchDiff(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+		return
+	}
+
+	var savedSearch models.SavedSearch
+	if err := savedSearchScope(c, userID).Where("id = ?", c.Param("id")).First(&savedSearch).Error; err != nil {
+		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Saved search not found")
+		return
+	}
+
+	query := database.DB.Where("saved_search_id = ? AND status = ?", savedSearch.ID, "done")
+	if runID := c.Param("runId"); runID != "latest" {
+		query = query.Where("id = ?", runID)
+	}
+
+	var run models.SavedSearchRun
+	if err := query.Order("id DESC").First(&run).Error; err != nil {
+		response.SendGinResponse(c, http.StatusNotFound, nil, nil, "Saved search run not found")
+		return
+	}
+
+	formatName := c.Query("format")
+	if formatName == "" {
+		changes := run.Changes
+		if changes == nil {
+			changes = []models.PlaceChange{}
+		}
+		response.SendGinResponse(c, http.StatusOK, gin.H{"run": run, "changes": changes}, nil, "")
+		return
+	}
+
+	format, err := export.LookupFormat(formatName)
+	if err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+
+	opts := export.DefaultOptions()
+	if delimiter := c.Query("delimiter"); delimiter != "" {
+		opts.Delimiter = delimiter
+	}
+	if language := c.Query("lang"); language != "" {
+		opts.Language = language
+	}
+
+	data, err := export.Diff(run.Changes, format, opts)
+	if err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s_diff_%d.%s", savedSearch.Name, run.ID, format.Extension))
+	c.Header("Content-Type", format.ContentType)
+	c.Data(http.StatusOK, format.ContentType, data)
+}
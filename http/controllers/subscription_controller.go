@@ -2,12 +2,15 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"medina-consultancy-api/database"
 	"medina-consultancy-api/models"
+	"medina-consultancy-api/pkg/billing"
 	"medina-consultancy-api/pkg/jwt"
 	mercadopago "medina-consultancy-api/pkg/mercado_pago"
+	"medina-consultancy-api/pkg/pagination"
 	"medina-consultancy-api/pkg/response"
 	"net/http"
 	"time"
@@ -105,13 +108,13 @@ func GetSubscriptionStatus(c *gin.Context) {
 	}
 
 	billingMonth := time.Now().Format("2006-01")
-	var queryCount int64
-	database.DB.Model(&models.IntegrationQuery{}).
-		Where("subscription_id = ? AND billing_month = ?", subscription.ID, billingMonth).
-		Count(&queryCount)
+	queryCount, reusedCount, err := billing.MonthlyQueryCounts(subscription.ID, billingMonth)
+	if err != nil {
+		log.Printf("Failed to count integration queries: %v", err)
+	}
 
 	unitPrice := CalculateUnitPrice(int(queryCount))
-	estimatedTotal := unitPrice * float64(queryCount)
+	estimatedTotal := unitPrice*float64(queryCount) + billing.ReusePrice()*float64(reusedCount)
 
 	tier := "0-99"
 	if queryCount >= 200 {
@@ -126,8 +129,10 @@ func GetSubscriptionStatus(c *gin.Context) {
 		"current_period_start": subscription.CurrentPeriodStart,
 		"current_period_end":   subscription.CurrentPeriodEnd,
 		"queries_this_month":   queryCount,
+		"reused_this_month":    reusedCount,
 		"current_tier":         tier,
 		"unit_price":           fmt.Sprintf("%.2f", unitPrice),
+		"reuse_price":          fmt.Sprintf("%.2f", billing.ReusePrice()),
 		"estimated_total":      fmt.Sprintf("%.2f", estimatedTotal),
 	}, nil, "")
 }
@@ -176,13 +181,27 @@ func GetInvoices(c *gin.Context) {
 		return
 	}
 
-	var invoices []models.Invoice
-	if err := database.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&invoices).Error; err != nil {
+	params, err := pagination.Parse(c)
+	if err != nil {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+
+	invoices, meta, err := pagination.Find[models.Invoice](database.DB.Where("user_id = ?", userID), params, pagination.Columns{
+		Status:   "status",
+		Sortable: []string{"billing_month"},
+	})
+	if errors.Is(err, pagination.ErrInvalidSort) {
+		response.SendGinResponse(c, http.StatusBadRequest, nil, nil, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to fetch invoices: %v", err)
 		response.SendGinResponse(c, http.StatusInternalServerError, nil, nil, "Failed to fetch invoices")
 		return
 	}
 
-	response.SendGinResponse(c, http.StatusOK, invoices, nil, "")
+	response.SendGinResponse(c, http.StatusOK, invoices, meta, "")
 }
 
 func RegenerateToken(c *gin.Context) {
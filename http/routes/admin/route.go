@@ -0,0 +1,22 @@
+package admin
+
+import (
+	"medina-consultancy-api/http/controllers"
+	middleware "medina-consultancy-api/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAdminRoutes(r *gin.RouterGroup) {
+	r.Use(middleware.ContentTypeMiddleware())
+	r.Use(middleware.AuthMiddleware())
+	r.Use(middleware.AdminMiddleware())
+
+	r.GET("/cities/:city/regions", controllers.GetCityRegions)
+	r.POST("/cities/:city/regions", controllers.CreateCityRegion)
+	r.PUT("/regions/:id", controllers.UpdateCityRegion)
+	r.DELETE("/regions/:id", controllers.DeleteCityRegion)
+
+	r.GET("/pricing", controllers.GetPricing)
+	r.PUT("/pricing", controllers.UpdatePricing)
+}
@@ -13,9 +13,12 @@ func RegisterCheckoutRoutes(r *gin.RouterGroup) {
 	r.GET("/packages", controllers.GetCreditPackages)
 	r.GET("/packages/:id", controllers.GetCreditPackageByID)
 
+	r.POST("/webhook/mercadopago", controllers.MercadoPagoWebhook)
+
 	r.POST("/create", middleware.AuthMiddleware(), controllers.CreateCheckout)
 	r.GET("/orders", middleware.AuthMiddleware(), controllers.GetUserOrders)
 	r.GET("/orders/:id", middleware.AuthMiddleware(), controllers.GetOrderStatus)
 	r.GET("/orders/:id/check", middleware.AuthMiddleware(), controllers.CheckPaymentStatus) // polling endpoint
 	r.GET("/credits", middleware.AuthMiddleware(), controllers.GetUserCredits)
+	r.GET("/credits/history", middleware.AuthMiddleware(), controllers.GetCreditHistory)
 }
@@ -14,6 +14,27 @@ func RegisterConsultancyRoutes(r *gin.RouterGroup) {
 	r.GET("/keywords", controllers.GetKeywordSuggestions)
 
 	r.POST("/search", middleware.AuthMiddleware(), controllers.FindLocationsBasedOnAddress)
+	r.POST("/search/estimate", middleware.AuthMiddleware(), controllers.EstimateSearch)  // never calls the place provider
+	r.GET("/search/:searchId", middleware.AuthMiddleware(), controllers.GetSearchStatus) // polling endpoint for async searches
 	r.GET("/search/:searchId/csv", middleware.AuthMiddleware(), controllers.DownloadSearchCSV)
+	r.GET("/search/:searchId/results", middleware.AuthMiddleware(), controllers.GetSearchResults)
+	r.GET("/search/:searchId/export", middleware.AuthMiddleware(), controllers.ExportSearch) // ?format=csv|xlsx|json|ndjson|vcf&template=
 	r.GET("/searches", middleware.AuthMiddleware(), controllers.GetUserSearches)
+
+	r.POST("/search/batch", middleware.AuthMiddleware(), controllers.CreateSearchBatch)
+	r.GET("/search/batch/:batchId", middleware.AuthMiddleware(), controllers.GetSearchBatch)
+	r.GET("/search/batch/:batchId/export", middleware.AuthMiddleware(), controllers.ExportSearchBatch) // merged results of the finished searches
+
+	r.GET("/export-columns", controllers.GetExportColumns)
+	r.GET("/export-templates", middleware.AuthMiddleware(), controllers.GetExportTemplates)
+	r.POST("/export-templates", middleware.AuthMiddleware(), controllers.CreateExportTemplate)
+	r.PUT("/export-templates/:id", middleware.AuthMiddleware(), controllers.UpdateExportTemplate)
+	r.DELETE("/export-templates/:id", middleware.AuthMiddleware(), controllers.DeleteExportTemplate)
+
+	r.GET("/saved-searches", middleware.AuthMiddleware(), controllers.GetSavedSearches)
+	r.POST("/saved-searches", middleware.AuthMiddleware(), controllers.CreateSavedSearch)
+	r.PUT("/saved-searches/:id", middleware.AuthMiddleware(), controllers.UpdateSavedSearch)
+	r.DELETE("/saved-searches/:id", middleware.AuthMiddleware(), controllers.DeleteSavedSearch)
+	r.GET("/saved-searches/:id/runs", middleware.AuthMiddleware(), controllers.GetSavedSearchRuns)
+	r.GET("/saved-searches/:id/runs/:runId/diff", middleware.AuthMiddleware(), controllers.GetSavedSearchDiff) // ?format=csv|xlsx|json, runId can be latest
 }
@@ -12,5 +12,17 @@ func RegisterIntegrationRoutes(r *gin.RouterGroup) {
 	r.Use(middleware.IntegrationAuthMiddleware())
 
 	r.POST("/search", controllers.IntegrationSearch)
+	r.POST("/search/batch", controllers.CreateSearchBatch) // runs asynchronously, each search billed as a query
+	r.GET("/search/batch/:batchId", controllers.GetSearchBatch)
+	r.GET("/search/batch/:batchId/export", controllers.ExportSearchBatch)
 	r.GET("/usage", controllers.GetUsage)
+	r.GET("/queries", controllers.GetIntegrationQueries)
+
+	// runs of these saved searches count as integration queries
+	r.GET("/saved-searches", controllers.GetSavedSearches)
+	r.POST("/saved-searches", controllers.CreateSavedSearch)
+	r.PUT("/saved-searches/:id", controllers.UpdateSavedSearch)
+	r.DELETE("/saved-searches/:id", controllers.DeleteSavedSearch)
+	r.GET("/saved-searches/:id/runs", controllers.GetSavedSearchRuns)
+	r.GET("/saved-searches/:id/runs/:runId/diff", controllers.GetSavedSearchDiff)
 }
@@ -0,0 +1,29 @@
+package leadsRoutes
+
+import (
+	"medina-consultancy-api/http/controllers"
+	middleware "medina-consultancy-api/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterLeadsRoutes(r *gin.RouterGroup) {
+	r.Use(middleware.ContentTypeMiddleware())
+	r.Use(middleware.AuthMiddleware())
+
+	r.GET("", controllers.GetLeads) // ?status=&tag=&list_id=&search_id=&query=
+	r.POST("", controllers.AddLeads)
+	r.GET("/export", controllers.ExportLeads) // ?format=csv|xlsx|json plus the lead filters
+	r.GET("/:id", controllers.GetLead)
+	r.PUT("/:id", controllers.UpdateLead)
+	r.DELETE("/:id", controllers.DeleteLead)
+	r.POST("/:id/notes", controllers.AddLeadNote)
+	r.DELETE("/:id/notes/:noteId", controllers.DeleteLeadNote)
+
+	r.GET("/lists", controllers.GetLeadLists)
+	r.POST("/lists", controllers.CreateLeadList)
+	r.PUT("/lists/:listId", controllers.UpdateLeadList)
+	r.DELETE("/lists/:listId", controllers.DeleteLeadList)
+	r.POST("/lists/:listId/leads", controllers.AddLeadsToList)
+	r.DELETE("/lists/:listId/leads/:id", controllers.RemoveLeadFromList)
+}
@@ -1,10 +1,12 @@
 package routes
 
 import (
+	adminRoutes "medina-consultancy-api/http/routes/admin"
 	authRoutes "medina-consultancy-api/http/routes/auth"
 	checkoutRoutes "medina-consultancy-api/http/routes/checkout"
 	consultancyRoutes "medina-consultancy-api/http/routes/consultancy"
 	integrationRoutes "medina-consultancy-api/http/routes/integration"
+	leadsRoutes "medina-consultancy-api/http/routes/leads"
 	subscriptionRoutes "medina-consultancy-api/http/routes/subscription"
 
 	"github.com/gin-gonic/gin"
@@ -36,6 +38,16 @@ func HandleRequest(r *gin.Engine) {
 		integrationRoutes.RegisterIntegrationRoutes(integrationPath)
 	}
 
+	leadsPath := r.Group("/api/v1/leads")
+	{
+		leadsRoutes.RegisterLeadsRoutes(leadsPath)
+	}
+
+	adminPath := r.Group("/api/v1/admin")
+	{
+		adminRoutes.RegisterAdminRoutes(adminPath)
+	}
+
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
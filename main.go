@@ -8,6 +8,8 @@ import (
 	"medina-consultancy-api/database"
 	"medina-consultancy-api/http/routes"
 	"medina-consultancy-api/pkg/billing"
+	"medina-consultancy-api/pkg/reconciliation"
+	"medina-consultancy-api/pkg/search"
 	"os"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -56,6 +58,22 @@ func BillingHandler(ctx context.Context) error {
 	return billing.ProcessMonthlyBilling()
 }
 
+func SearchWorkerHandler(ctx context.Context) error {
+	log.Println("Starting search worker...")
+	return search.ProcessSearchJobs(ctx)
+}
+
+func SavedSearchesHandler(ctx context.Context) error {
+	log.Println("Starting saved searches...")
+	return search.ProcessSavedSearches(ctx)
+}
+
+func ReconciliationHandler(ctx context.Context) error {
+	log.Println("Starting order reconciliation...")
+	_, err := reconciliation.ReconcilePendingOrders(ctx)
+	return err
+}
+
 func main() {
 	fmt.Println("Iniciando projeto MedinaConsultancy...")
 
@@ -64,6 +82,12 @@ func main() {
 	switch mode {
 	case "billing":
 		lambda.Start(BillingHandler)
+	case "search-worker":
+		lambda.Start(SearchWorkerHandler)
+	case "reconcile":
+		lambda.Start(ReconciliationHandler)
+	case "saved-searches":
+		lambda.Start(SavedSearchesHandler)
 	case "local":
 		r := setupRouter()
 		port := os.Getenv("PORT")
@@ -80,6 +104,24 @@ func main() {
 			log.Fatalf("Billing failed: %v", err)
 		}
 		log.Println("Billing completed successfully.")
+	case "search-worker-local":
+		log.Println("Running search worker locally...")
+		if err := search.ProcessSearchJobs(context.Background()); err != nil {
+			log.Fatalf("Search worker failed: %v", err)
+		}
+		log.Println("Search worker completed successfully.")
+	case "saved-searches-local":
+		log.Println("Running saved searches locally...")
+		if err := search.ProcessSavedSearches(context.Background()); err != nil {
+			log.Fatalf("Saved searches failed: %v", err)
+		}
+		log.Println("Saved searches completed successfully.")
+	case "reconcile-local":
+		log.Println("Running order reconciliation locally...")
+		if _, err := reconciliation.ReconcilePendingOrders(context.Background()); err != nil {
+			log.Fatalf("Reconciliation failed: %v", err)
+		}
+		log.Println("Reconciliation completed successfully.")
 	default:
 		lambda.Start(Handler)
 	}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"medina-consultancy-api/database"
+	"medina-consultancy-api/models"
+	"medina-consultancy-api/pkg/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminMiddleware must run after AuthMiddleware; it checks the flag in the database so
+// that revoking admin access does not wait for the token to expire.
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userID")
+		if !exists {
+			response.SendGinResponse(c, http.StatusUnauthorized, nil, nil, "User not authenticated")
+			c.Abort()
+			return
+		}
+
+		var user models.User
+		if err := database.DB.First(&user, userID).Error; err != nil || !user.IsAdmin {
+			response.SendGinResponse(c, http.StatusForbidden, nil, nil, "Admin access required")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
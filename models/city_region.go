@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type CityRegion struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	City      string         `gorm:"uniqueIndex:idx_city_regions_unique_name,where:deleted_at IS NULL;not null" json:"city"` // textutil.CityKey of the city name
+	Name      string         `gorm:"uniqueIndex:idx_city_regions_unique_name,where:deleted_at IS NULL;not null" json:"name"`
+	Position  int            `gorm:"default:0" json:"position"`
+	Active    bool           `gorm:"default:true" json:"active"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
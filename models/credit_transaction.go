@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type CreditTransaction struct {
+	ID            uint           `gorm:"primarykey" json:"id"`
+	UserID        uint           `gorm:"index;not null" json:"user_id"`
+	User          User           `gorm:"foreignKey:UserID" json:"-"`
+	Type          string         `gorm:"not null" json:"type"`   // debit, credit, refund, adjustment
+	Amount        int            `gorm:"not null" json:"amount"` // signed, negative for debits
+	BalanceAfter  int            `gorm:"not null" json:"balance_after"`
+	ReferenceType string         `gorm:"index:idx_credit_transactions_reference" json:"reference_type"` // search, saved_search, order
+	ReferenceID   string         `gorm:"index:idx_credit_transactions_reference" json:"reference_id"`
+	Reason        string         `json:"reason"`
+	Status        string         `gorm:"index" json:"status,omitempty"` // reserved, committed, released (reservation debits only)
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+}
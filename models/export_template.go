@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ExportTemplate struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	UserID    uint           `gorm:"uniqueIndex:idx_export_templates_unique_name,where:deleted_at IS NULL;not null" json:"user_id"`
+	User      User           `gorm:"foreignKey:UserID" json:"-"`
+	Name      string         `gorm:"uniqueIndex:idx_export_templates_unique_name,where:deleted_at IS NULL;not null" json:"name"`
+	Columns   []string       `gorm:"serializer:json;type:text" json:"columns"`
+	Delimiter string         `gorm:"default:';'" json:"delimiter"`
+	Language  string         `gorm:"default:pt-BR" json:"language"`
+	E164      bool           `gorm:"default:false" json:"e164"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
@@ -17,6 +17,9 @@ type IntegrationQuery struct {
 	Results        int            `gorm:"default:0" json:"results"`
 	BucketURL      string         `json:"bucket_url"`
 	BillingMonth   string         `gorm:"index;not null" json:"billing_month"` // "2026-03" format
+	Fingerprint    string         `gorm:"index" json:"-"`
+	Reused         bool           `gorm:"default:false" json:"reused"` // billed at the re-use price, outside of the tiers
+	ReusedFrom     string         `json:"reused_from,omitempty"`
 	CreatedAt      time.Time      `json:"created_at"`
 	UpdatedAt      time.Time      `json:"updated_at"`
 	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
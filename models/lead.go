@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// Lead is a place a user works on as a sales prospect. A place has at most one lead per
+// user no matter how many searches returned it.
+type Lead struct {
+	ID              uint       `gorm:"primarykey" json:"id"`
+	UserID          uint       `gorm:"uniqueIndex:idx_leads_user_place;not null" json:"user_id"`
+	User            User       `gorm:"foreignKey:UserID" json:"-"`
+	PlaceID         uint       `gorm:"uniqueIndex:idx_leads_user_place;not null" json:"place_id"`
+	Place           Place      `gorm:"foreignKey:PlaceID" json:"place"`
+	Status          string     `gorm:"index;default:new;not null" json:"status"` // new, contacted, qualified, won or lost
+	StatusChangedAt *time.Time `json:"status_changed_at"`
+	Tags            []string   `gorm:"serializer:json;type:text" json:"tags"`
+	SearchIDs       []string   `gorm:"serializer:json;type:text" json:"search_ids"` // Search.SearchID of the searches that returned the place
+	Lists           []LeadList `gorm:"many2many:lead_list_items" json:"lists,omitempty"`
+	Notes           []LeadNote `gorm:"constraint:OnDelete:CASCADE" json:"notes,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// LeadList is a named group of leads, a lead can be in several lists.
+type LeadList struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	UserID    uint      `gorm:"uniqueIndex:idx_lead_lists_user_name;not null" json:"user_id"`
+	User      User      `gorm:"foreignKey:UserID" json:"-"`
+	Name      string    `gorm:"uniqueIndex:idx_lead_lists_user_name;not null" json:"name"`
+	Leads     []Lead    `gorm:"many2many:lead_list_items" json:"-"`
+	LeadCount int64     `gorm:"->;-:migration" json:"lead_count"` // only loaded when listing
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import (
+	"time"
+)
+
+type LeadNote struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	LeadID    uint      `gorm:"index;not null" json:"lead_id"`
+	UserID    uint      `gorm:"not null" json:"user_id"`
+	Body      string    `gorm:"type:text;not null" json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
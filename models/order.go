@@ -13,7 +13,7 @@ type Order struct {
 	CreditPackageID   uint           `gorm:"not null" json:"credit_package_id"`
 	CreditPackage     CreditPackage  `gorm:"foreignKey:CreditPackageID" json:"credit_package"`
 	Amount            string         `gorm:"not null" json:"amount"`
-	Status            string         `gorm:"default:pending" json:"status"` // pending, approved, rejected, cancelled, in_process
+	Status            string         `gorm:"default:pending" json:"status"` // pending, approved, rejected, cancelled, in_process, expired
 	MercadoPagoID     string         `json:"mercado_pago_id"`
 	ExternalReference string         `gorm:"uniqueIndex" json:"external_reference"`
 	QRCode            string         `gorm:"type:text" json:"qr_code"`
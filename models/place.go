@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+)
+
+// Place is a business as last seen by a provider. The same place is shared by every
+// search that returned it and refreshed each time it shows up again.
+type Place struct {
+	ID                   uint      `gorm:"primarykey" json:"id"`
+	Provider             string    `gorm:"uniqueIndex:idx_places_provider_external_id;not null" json:"provider"`
+	ExternalID           string    `gorm:"uniqueIndex:idx_places_provider_external_id;not null" json:"place_id"`
+	Name                 string    `gorm:"not null" json:"name"`
+	FormattedAddress     string    `json:"formatted_address"`
+	FormattedPhoneNumber string    `json:"formatted_phone_number"`
+	Website              string    `json:"website"`
+	URL                  string    `json:"url"`
+	Rating               float64   `gorm:"default:0" json:"rating"`
+	UserRatingsTotal     int       `gorm:"default:0" json:"user_ratings_total"`
+	PriceLevel           int       `gorm:"default:0" json:"price_level"`
+	BusinessStatus       string    `json:"business_status"`
+	OpenNow              bool      `gorm:"default:false" json:"open_now"`
+	WeekdayText          []string  `gorm:"serializer:json;type:text" json:"weekday_text"`
+	Types                []string  `gorm:"serializer:json;type:text" json:"types"`
+	Lat                  float64   `json:"lat"`
+	Lng                  float64   `json:"lng"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+
+	// FormattedPhoneNumber normalized for the country searched
+	PhoneE164      string `gorm:"column:phone_e164" json:"phone_e164"`
+	PhoneType      string `json:"phone_type"`
+	WhatsAppLikely bool   `gorm:"column:whatsapp_likely;default:false" json:"whatsapp_likely"`
+
+	// contacts from the website, kept until the place is enriched again
+	Emails        []string          `gorm:"serializer:json;type:text" json:"emails"`
+	WebsitePhones []string          `gorm:"serializer:json;type:text" json:"website_phones"`
+	SocialLinks   map[string]string `gorm:"serializer:json;type:text" json:"social_links"`
+	WhatsApp      string            `gorm:"column:whatsapp" json:"whatsapp"`
+	EnrichedAt    *time.Time        `json:"enriched_at"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+)
+
+type PlaceCacheEntry struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	Key       string    `gorm:"uniqueIndex;not null" json:"key"`
+	Value     string    `gorm:"type:text" json:"value"`
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// PricingConfig sets the credit cost of searches. Admins save a new row for every change,
+// the latest one is in effect.
+type PricingConfig struct {
+	ID                uint      `gorm:"primarykey" json:"id"`
+	StandardCredits   int       `gorm:"not null" json:"standard_credits"`   // price of a standard search
+	StandardQueries   int       `gorm:"not null" json:"standard_queries"`   // queries covered by StandardCredits, other depths pay proportionally
+	MinimumCredits    int       `gorm:"not null" json:"minimum_credits"`    // least a search costs before options, a quick search
+	DetailsCredits    int       `gorm:"not null" json:"details_credits"`    // fetching the phone and website of the places
+	EnrichmentCredits int       `gorm:"not null" json:"enrichment_credits"` // visiting the websites
+	ResultCredits     float64   `gorm:"not null" json:"result_credits"`     // per place found, 0 disables
+	ResultCreditsCap  int       `gorm:"not null" json:"result_credits_cap"` // most charged for the places of one search
+	UpdatedBy         uint      `json:"updated_by,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// CreditQuote is the price of one search. The base is known up front, the result charge
+// only once the search ran, so the cap is reserved and what is not used given back.
+type CreditQuote struct {
+	Queries       int     `json:"queries"`
+	Search        int     `json:"search"`
+	Details       int     `json:"details"`
+	Enrichment    int     `json:"enrichment"`
+	ResultCredits float64 `json:"result_credits,omitempty"`
+	ResultCap     int     `json:"result_cap,omitempty"`
+}
+
+// Base is the part of the price that does not depend on the results.
+func (q CreditQuote) Base() int {
+	return q.Search + q.Details + q.Enrichment
+}
+
+// Reserved is the most the search can cost, debited before it runs.
+func (q CreditQuote) Reserved() int {
+	return q.Base() + q.ResultCap
+}
+
+// ResultCharge is what the places found cost, rounded up and capped.
+func (q CreditQuote) ResultCharge(results int) int {
+	if q.ResultCredits <= 0 {
+		return 0
+	}
+	// drop the float error first, 1.1 * 100 is 110.00000000000001
+	charge := math.Round(q.ResultCredits*float64(results)*1e6) / 1e6
+	return min(int(math.Ceil(charge)), q.ResultCap)
+}
+
+// Charge is the final price of a search that found the given number of places.
+func (q CreditQuote) Charge(results int) int {
+	return q.Base() + q.ResultCharge(results)
+}
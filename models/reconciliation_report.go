@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ReconciliationReport struct {
+	ID              uint           `gorm:"primarykey" json:"id"`
+	StartedAt       time.Time      `gorm:"not null" json:"started_at"`
+	FinishedAt      time.Time      `json:"finished_at"`
+	OrdersScanned   int            `gorm:"default:0" json:"orders_scanned"`
+	OrdersCredited  int            `gorm:"default:0" json:"orders_credited"`
+	InvoicesScanned int            `gorm:"default:0" json:"invoices_scanned"`
+	InvoicesPaid    int            `gorm:"default:0" json:"invoices_paid"`
+	Unchanged       int            `gorm:"default:0" json:"unchanged"`
+	Errors          int            `gorm:"default:0" json:"errors"`
+	Transitions     map[string]int `gorm:"serializer:json;type:text" json:"transitions"` // "pending->approved": 3, "invoice processing->paid": 1
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+}
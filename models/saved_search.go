@@ -0,0 +1,29 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SavedSearch is a search definition re-run on a schedule to follow a market over time.
+// Runs debit credits from the user, or count as integration queries when the saved search
+// belongs to a subscription.
+type SavedSearch struct {
+	ID             uint            `gorm:"primarykey" json:"id"`
+	UserID         uint            `gorm:"index;not null" json:"user_id"`
+	User           User            `gorm:"foreignKey:UserID" json:"-"`
+	SubscriptionID *uint           `gorm:"index" json:"subscription_id,omitempty"`
+	Name           string          `gorm:"not null" json:"name"`
+	Request        json.RawMessage `gorm:"serializer:json;type:text;not null" json:"request"` // search.CityRequest
+	Schedule       string          `gorm:"not null" json:"schedule"`                          // daily, weekly or monthly
+	Active         bool            `gorm:"default:true;not null" json:"active"`
+	NextRunAt      time.Time       `gorm:"index;not null" json:"next_run_at"`
+	LastRunAt      *time.Time      `json:"last_run_at"`
+	LastSearchID   string          `json:"last_search_id,omitempty"`
+	Runs           int             `gorm:"default:0" json:"runs"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt  `gorm:"index" json:"-"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+)
+
+// SavedSearchRun is one execution of a saved search and what changed since the previous
+// successful one.
+type SavedSearchRun struct {
+	ID            uint            `gorm:"primarykey" json:"id"`
+	SavedSearchID uint            `gorm:"index;not null" json:"saved_search_id"`
+	SearchID      string          `gorm:"index" json:"search_id,omitempty"` // search or integration query made by the run
+	Status        string          `gorm:"not null" json:"status"`           // running, done, failed or skipped
+	Error         string          `json:"error,omitempty"`
+	CreditsUsed   int             `gorm:"default:0" json:"credits_used"`
+	Results       int             `gorm:"default:0" json:"results"`
+	Baseline      bool            `gorm:"default:false" json:"baseline"` // first run, nothing to compare with
+	NewPlaces     int             `gorm:"default:0" json:"new_places"`
+	ClosedPlaces  int             `gorm:"default:0" json:"closed_places"`
+	ChangedPlaces int             `gorm:"default:0" json:"changed_places"`
+	Changes       []PlaceChange   `gorm:"serializer:json;type:text" json:"-"`
+	Snapshot      []PlaceSnapshot `gorm:"serializer:json;type:text" json:"-"` // compared by the next run
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// PlaceSnapshot keeps the fields compared between runs, places are shared between
+// searches and refreshed by any of them.
+type PlaceSnapshot struct {
+	PlaceID        string `json:"place_id"`
+	Name           string `json:"name"`
+	Address        string `json:"address"`
+	Phone          string `json:"phone"`
+	Website        string `json:"website"`
+	BusinessStatus string `json:"business_status"`
+}
+
+// PlaceChange is a place that appeared, closed or changed its contacts between two runs.
+type PlaceChange struct {
+	Change          string `json:"change"` // new, closed or changed
+	PlaceID         string `json:"place_id"`
+	Name            string `json:"name"`
+	Address         string `json:"address"`
+	Phone           string `json:"phone"`
+	Website         string `json:"website"`
+	BusinessStatus  string `json:"business_status"`
+	Missing         bool   `json:"missing,omitempty"` // closed because the provider stopped returning it
+	PreviousPhone   string `json:"previous_phone,omitempty"`
+	PreviousWebsite string `json:"previous_website,omitempty"`
+}
@@ -7,16 +7,33 @@ import (
 )
 
 type Search struct {
-	ID        uint           `gorm:"primarykey" json:"id"`
-	UserID    uint           `gorm:"not null" json:"user_id"`
-	User      User           `gorm:"foreignKey:UserID" json:"-"`
-	SearchID  string         `gorm:"uniqueIndex;not null" json:"search_id"`
-	Query     string         `gorm:"not null" json:"query"`
-	City      string         `gorm:"not null" json:"city"`
-	BucketURL string         `gorm:"not null" json:"bucket_url"`
-	FileName  string         `gorm:"not null" json:"file_name"`
-	Results   int            `gorm:"default:0" json:"results"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID              uint            `gorm:"primarykey" json:"id"`
+	UserID          uint            `gorm:"not null" json:"user_id"`
+	User            User            `gorm:"foreignKey:UserID" json:"-"`
+	SearchID        string          `gorm:"uniqueIndex;not null" json:"search_id"`
+	Query           string          `gorm:"not null" json:"query"`
+	City            string          `gorm:"not null" json:"city"`
+	BucketURL       string          `gorm:"not null" json:"bucket_url"`
+	FileName        string          `gorm:"not null" json:"file_name"`
+	Results         int             `gorm:"default:0" json:"results"`
+	Fingerprint     string          `gorm:"index" json:"-"`        // search.CityRequest.Fingerprint
+	ReusedFrom      string          `json:"reused_from,omitempty"` // SearchID of the search whose results were re-used
+	Warnings        []SearchWarning `gorm:"serializer:json;type:text" json:"warnings,omitempty"`
+	DetailsMissing  int             `gorm:"default:0" json:"details_missing"`
+	CreditsRefunded int             `gorm:"default:0" json:"credits_refunded"` // for empty or partial results
+	ExcludedSeen    int             `gorm:"default:0" json:"excluded_seen"`    // places already delivered, left out by only_new
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt  `gorm:"index" json:"-"`
+}
+
+// SearchWarning is a provider call that failed during a search. Details failures are
+// grouped by status, with Count places affected.
+type SearchWarning struct {
+	Stage   string `json:"stage"` // search or details
+	Query   string `json:"query,omitempty"`
+	Page    int    `json:"page,omitempty"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+	Count   int    `json:"count,omitempty"`
 }
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// SearchBatch groups searches submitted together. Each one runs as a SearchJob with the
+// batch ID, the batch status is derived from them.
+type SearchBatch struct {
+	ID             uint      `gorm:"primarykey" json:"id"`
+	BatchID        string    `gorm:"uniqueIndex;not null" json:"batch_id"`
+	UserID         uint      `gorm:"index;not null" json:"user_id"`
+	User           User      `gorm:"foreignKey:UserID" json:"-"`
+	SubscriptionID *uint     `gorm:"index" json:"subscription_id,omitempty"`
+	Name           string    `json:"name,omitempty"`
+	Items          int       `gorm:"not null" json:"items"`
+	CreditsUsed    int       `gorm:"default:0" json:"credits_used"` // reserved up front, refunds are settled per item
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
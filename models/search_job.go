@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SearchJob struct {
+	ID             uint           `gorm:"primarykey" json:"id"`
+	UserID         uint           `gorm:"index;not null" json:"user_id"`
+	User           User           `gorm:"foreignKey:UserID" json:"-"`
+	SearchID       string         `gorm:"uniqueIndex;not null" json:"search_id"`
+	SubscriptionID *uint          `gorm:"index" json:"subscription_id,omitempty"`      // saved as an integration query instead of a search
+	BatchID        string         `gorm:"index" json:"batch_id,omitempty"`             // SearchBatch.BatchID
+	Status         string         `gorm:"default:queued;index;not null" json:"status"` // queued, running, done, failed
+	Request        string         `gorm:"type:text;not null" json:"-"`                 // JSON encoded search.CityRequest
+	CreditsUsed    int            `gorm:"default:0" json:"credits_used"`
+	Quote          CreditQuote    `gorm:"serializer:json;type:text" json:"-"` // price the credits were reserved at
+	RegionsTotal   int            `gorm:"default:0" json:"regions_total"`
+	RegionsDone    int            `gorm:"default:0" json:"regions_done"`
+	PartialResults int            `gorm:"default:0" json:"partial_results"`
+	Results        int            `gorm:"default:0" json:"results"`
+	FilteredOut    int            `gorm:"default:0" json:"filtered_out"`
+	ExcludedSeen   int            `gorm:"default:0" json:"excluded_seen"`
+	Error          string         `json:"error,omitempty"`
+	Attempts       int            `gorm:"default:0" json:"attempts"`
+	StartedAt      *time.Time     `json:"started_at"`
+	FinishedAt     *time.Time     `json:"finished_at"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+}
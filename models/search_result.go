@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+)
+
+type SearchResult struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	SearchID  string    `gorm:"uniqueIndex:idx_search_results_search_place;not null" json:"search_id"` // Search.SearchID or IntegrationQuery.SearchID
+	PlaceID   uint      `gorm:"uniqueIndex:idx_search_results_search_place;index;not null" json:"place_id"`
+	Place     Place     `gorm:"foreignKey:PlaceID" json:"place"`
+	Position  int       `gorm:"default:0" json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+)
+
+// SeenPlace records that a place was delivered to a user, or to one of their integration
+// subscriptions, so later searches with only_new can leave it out.
+type SeenPlace struct {
+	ID             uint      `gorm:"primarykey" json:"id"`
+	UserID         uint      `gorm:"uniqueIndex:idx_seen_places_owner_place;not null" json:"user_id"`
+	SubscriptionID uint      `gorm:"uniqueIndex:idx_seen_places_owner_place;default:0;not null" json:"subscription_id"` // 0 for searches made in the app
+	PlaceID        uint      `gorm:"uniqueIndex:idx_seen_places_owner_place;not null" json:"place_id"`
+	FirstSearchID  string    `gorm:"not null" json:"first_search_id"` // Search.SearchID or IntegrationQuery.SearchID
+	CreatedAt      time.Time `json:"created_at"`
+}
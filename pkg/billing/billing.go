@@ -7,11 +7,17 @@ import (
 	"medina-consultancy-api/database"
 	"medina-consultancy-api/models"
 	mercadopago "medina-consultancy-api/pkg/mercado_pago"
+	"medina-consultancy-api/pkg/payments"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+const defaultReusePrice = 1.00
+
 func CalculateUnitPrice(queryCount int) float64 {
 	switch {
 	case queryCount >= 200:
@@ -23,6 +29,31 @@ func CalculateUnitPrice(queryCount int) float64 {
 	}
 }
 
+// ReusePrice is charged for integration queries answered with the results of a recent
+// identical query (INTEGRATION_REUSE_PRICE, R$1.00 by default). They do not count
+// towards the tiers.
+func ReusePrice() float64 {
+	if price, err := strconv.ParseFloat(os.Getenv("INTEGRATION_REUSE_PRICE"), 64); err == nil && price >= 0 {
+		return price
+	}
+	return defaultReusePrice
+}
+
+// MonthlyQueryCounts returns how many full and re-used queries a subscription made in
+// the billing month.
+func MonthlyQueryCounts(subscriptionID uint, billingMonth string) (full int64, reused int64, err error) {
+	query := database.DB.Model(&models.IntegrationQuery{}).Where("subscription_id = ? AND billing_month = ?", subscriptionID, billingMonth)
+
+	if err := query.Session(&gorm.Session{}).Where("reused = ?", false).Count(&full).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to count queries: %w", err)
+	}
+	if err := query.Session(&gorm.Session{}).Where("reused = ?", true).Count(&reused).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to count re-used queries: %w", err)
+	}
+
+	return full, reused, nil
+}
+
 func ProcessMonthlyBilling() error {
 	billingMonth := time.Now().AddDate(0, -1, 0).Format("2006-01")
 	log.Printf("Processing billing for month: %s", billingMonth)
@@ -45,27 +76,31 @@ func ProcessMonthlyBilling() error {
 
 func processSubscriptionBilling(sub models.Subscription, billingMonth string) error {
 	var existingInvoice models.Invoice
-	if err := database.DB.Where("subscription_id = ? AND billing_month = ? AND status = ?", sub.ID, billingMonth, "paid").First(&existingInvoice).Error; err == nil {
-		log.Printf("Subscription %d already billed for %s, skipping", sub.ID, billingMonth)
+	if err := database.DB.Where("subscription_id = ? AND billing_month = ? AND status IN ?", sub.ID, billingMonth, []string{"paid", "processing"}).First(&existingInvoice).Error; err == nil {
+		log.Printf("Subscription %d already billed for %s (%s), skipping", sub.ID, billingMonth, existingInvoice.Status)
 		return nil
 	}
 
-	var queryCount int64
-	if err := database.DB.Model(&models.IntegrationQuery{}).
-		Where("subscription_id = ? AND billing_month = ?", sub.ID, billingMonth).
-		Count(&queryCount).Error; err != nil {
-		return fmt.Errorf("failed to count queries: %w", err)
+	queryCount, reusedCount, err := MonthlyQueryCounts(sub.ID, billingMonth)
+	if err != nil {
+		return err
 	}
 
-	if queryCount == 0 {
+	if queryCount == 0 && reusedCount == 0 {
 		log.Printf("Subscription %d has no queries for %s, skipping", sub.ID, billingMonth)
 		return nil
 	}
 
 	unitPrice := CalculateUnitPrice(int(queryCount))
-	totalAmount := unitPrice * float64(queryCount)
+	reusePrice := ReusePrice()
+	totalAmount := unitPrice*float64(queryCount) + reusePrice*float64(reusedCount)
+
+	log.Printf("Subscription %d: %d queries x R$%.2f + %d re-used x R$%.2f = R$%.2f", sub.ID, queryCount, unitPrice, reusedCount, reusePrice, totalAmount)
 
-	log.Printf("Subscription %d: %d queries x R$%.2f = R$%.2f", sub.ID, queryCount, unitPrice, totalAmount)
+	if totalAmount == 0 {
+		log.Printf("Subscription %d has nothing to pay for %s, skipping", sub.ID, billingMonth)
+		return nil
+	}
 
 	var invoice models.Invoice
 	if err := database.DB.Where("subscription_id = ? AND billing_month = ? AND status IN ?", sub.ID, billingMonth, []string{"pending", "failed"}).First(&invoice).Error; err != nil {
@@ -75,6 +110,8 @@ func processSubscriptionBilling(sub models.Subscription, billingMonth string) er
 			BillingMonth:   billingMonth,
 			QueryCount:     int(queryCount),
 			UnitPrice:      fmt.Sprintf("%.2f", unitPrice),
+			ReusedCount:    int(reusedCount),
+			ReusePrice:     fmt.Sprintf("%.2f", reusePrice),
 			TotalAmount:    fmt.Sprintf("%.2f", totalAmount),
 			Status:         "pending",
 		}
@@ -93,7 +130,7 @@ func processSubscriptionBilling(sub models.Subscription, billingMonth string) er
 
 	paymentResp, err := mercadopago.ChargeCard(ctx, mercadopago.CardPaymentRequest{
 		Amount:      totalAmount,
-		Description: fmt.Sprintf("Place Consult - %s (%d consultas)", billingMonth, queryCount),
+		Description: fmt.Sprintf("Place Consult - %s (%d consultas)", billingMonth, queryCount+reusedCount),
 		CustomerID:  sub.MPCustomerID,
 		CardID:      sub.MPCardID,
 		PayerEmail:  user.Email,
@@ -101,6 +138,7 @@ func processSubscriptionBilling(sub models.Subscription, billingMonth string) er
 	})
 
 	invoice.Attempts++
+	invoice.ExternalReference = externalRef
 
 	if err != nil {
 		log.Printf("Payment failed for subscription %d: %v", sub.ID, err)
@@ -115,18 +153,11 @@ func processSubscriptionBilling(sub models.Subscription, billingMonth string) er
 		return fmt.Errorf("payment failed: %w", err)
 	}
 
-	if paymentResp.Status == "approved" {
-		now := time.Now()
-		invoice.Status = "paid"
-		invoice.MercadoPagoID = paymentResp.ID
-		invoice.PaidAt = &now
-	} else {
-		invoice.Status = "failed"
-		invoice.MercadoPagoID = paymentResp.ID
+	// charges still in process are settled later through the Mercado Pago webhook
+	if err := payments.ApplyInvoiceStatus(&invoice, paymentResp.ID, paymentResp.Status); err != nil {
+		return err
 	}
 
-	database.DB.Save(&invoice)
-
 	log.Printf("Subscription %d billed successfully: R$%.2f (status: %s)", sub.ID, totalAmount, invoice.Status)
 	return nil
 }
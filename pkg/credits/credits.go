@@ -0,0 +1,112 @@
+package credits
+
+import (
+	"errors"
+	"fmt"
+	"medina-consultancy-api/database"
+	"medina-consultancy-api/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrInsufficientCredits = errors.New("insufficient credits")
+
+// Debit removes amount credits from the user only if the balance covers it, recording
+// the movement in the ledger within the same transaction. It returns the new balance.
+func Debit(userID uint, amount int, referenceType string, referenceID string, reason string) (int, error) {
+	var balance int
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		balance, err = apply(tx, userID, -amount, "debit", referenceType, referenceID, reason)
+		return err
+	})
+	return balance, err
+}
+
+// Credit adds amount credits to the user. txType is one of credit, refund or adjustment.
+func Credit(userID uint, amount int, txType string, referenceType string, referenceID string, reason string) (int, error) {
+	var balance int
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		balance, err = apply(tx, userID, amount, txType, referenceType, referenceID, reason)
+		return err
+	})
+	return balance, err
+}
+
+// CreditOrder adds the package credits of an approved order exactly once. It reports
+// whether this call was the one that credited the order.
+func CreditOrder(order *models.Order) (bool, error) {
+	if order.CreditPackage.ID == 0 {
+		if err := database.DB.First(&order.CreditPackage, order.CreditPackageID).Error; err != nil {
+			return false, fmt.Errorf("failed to fetch credit package: %w", err)
+		}
+	}
+
+	credited := false
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Order{}).
+			Where("id = ? AND credits_added = ?", order.ID, false).
+			Update("credits_added", true)
+		if result.Error != nil {
+			return fmt.Errorf("failed to mark order as credited: %w", result.Error)
+		}
+
+		// already credited by a concurrent poll or webhook
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		reason := fmt.Sprintf("Pacote %s", order.CreditPackage.Name)
+		if _, err := apply(tx, order.UserID, order.CreditPackage.Credits, "credit", "order", fmt.Sprintf("%d", order.ID), reason); err != nil {
+			return err
+		}
+
+		credited = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	order.CreditsAdded = true
+	return credited, nil
+}
+
+func apply(tx *gorm.DB, userID uint, delta int, txType string, referenceType string, referenceID string, reason string) (int, error) {
+	query := tx.Model(&models.User{}).Where("id = ?", userID)
+	if delta < 0 {
+		query = query.Where("credits >= ?", -delta)
+	}
+
+	result := query.UpdateColumn("credits", gorm.Expr("credits + ?", delta))
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to update credits: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		if delta < 0 {
+			return 0, ErrInsufficientCredits
+		}
+		return 0, fmt.Errorf("user %d not found", userID)
+	}
+
+	var user models.User
+	if err := tx.Select("credits").First(&user, userID).Error; err != nil {
+		return 0, fmt.Errorf("failed to read balance: %w", err)
+	}
+
+	entry := models.CreditTransaction{
+		UserID:        userID,
+		Type:          txType,
+		Amount:        delta,
+		BalanceAfter:  user.Credits,
+		ReferenceType: referenceType,
+		ReferenceID:   referenceID,
+		Reason:        reason,
+	}
+	if err := tx.Create(&entry).Error; err != nil {
+		return 0, fmt.Errorf("failed to record credit transaction: %w", err)
+	}
+
+	return user.Credits, nil
+}
@@ -0,0 +1,175 @@
+package credits
+
+import (
+	"fmt"
+	"log"
+	"medina-consultancy-api/database"
+	"medina-consultancy-api/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Reservation is a debit that is only final once committed. Releasing it refunds the
+// credits with a refund entry in the ledger.
+type Reservation struct {
+	UserID        uint
+	Amount        int
+	ReferenceType string
+	ReferenceID   string
+	Balance       int // balance right after the reservation
+}
+
+func Reserve(userID uint, amount int, referenceType string, referenceID string, reason string) (*Reservation, error) {
+	var balance int
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		balance, err = apply(tx, userID, -amount, "debit", referenceType, referenceID, reason)
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.CreditTransaction{}).
+			Where("reference_type = ? AND reference_id = ? AND type = ?", referenceType, referenceID, "debit").
+			Update("status", "reserved").Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reservation{
+		UserID:        userID,
+		Amount:        amount,
+		ReferenceType: referenceType,
+		ReferenceID:   referenceID,
+		Balance:       balance,
+	}, nil
+}
+
+func (r *Reservation) Commit() error {
+	return CommitReservation(r.ReferenceType, r.ReferenceID)
+}
+
+func (r *Reservation) CommitPartial(used int, reason string) (int, error) {
+	return CommitPartial(r.ReferenceType, r.ReferenceID, used, reason)
+}
+
+func (r *Reservation) Release(reason string) (int, error) {
+	return ReleaseReservation(r.ReferenceType, r.ReferenceID, reason)
+}
+
+// CommitPartial makes a reserved debit final for used credits and refunds the rest,
+// returning the user's balance. Only the first call on a reservation refunds anything.
+func CommitPartial(referenceType string, referenceID string, used int, reason string) (int, error) {
+	var reserved models.CreditTransaction
+	if err := database.DB.Where("reference_type = ? AND reference_id = ? AND type = ?", referenceType, referenceID, "debit").
+		First(&reserved).Error; err != nil {
+		return 0, fmt.Errorf("reservation not found: %w", err)
+	}
+
+	balance := -1
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.CreditTransaction{}).
+			Where("id = ? AND status = ?", reserved.ID, "reserved").
+			Update("status", "committed")
+		if result.Error != nil {
+			return fmt.Errorf("failed to commit reservation: %w", result.Error)
+		}
+
+		// debits are stored negative
+		refund := -reserved.Amount - used
+		if result.RowsAffected == 0 || refund <= 0 {
+			return nil
+		}
+
+		var err error
+		balance, err = apply(tx, reserved.UserID, refund, "refund", referenceType, referenceID, reason)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if balance < 0 {
+		var user models.User
+		if err := database.DB.Select("credits").First(&user, reserved.UserID).Error; err != nil {
+			return 0, fmt.Errorf("failed to read balance: %w", err)
+		}
+		balance = user.Credits
+	}
+
+	return balance, nil
+}
+
+// CommitReservation makes a reserved debit final. Committing twice is a no-op.
+func CommitReservation(referenceType string, referenceID string) error {
+	return database.DB.Model(&models.CreditTransaction{}).
+		Where("reference_type = ? AND reference_id = ? AND type = ? AND status = ?", referenceType, referenceID, "debit", "reserved").
+		Update("status", "committed").Error
+}
+
+// ReleaseReservation refunds a reserved debit and returns the user's balance. Only the
+// first release of a reservation refunds anything; committed reservations are left as is.
+func ReleaseReservation(referenceType string, referenceID string, reason string) (int, error) {
+	var reserved models.CreditTransaction
+	if err := database.DB.Where("reference_type = ? AND reference_id = ? AND type = ?", referenceType, referenceID, "debit").
+		First(&reserved).Error; err != nil {
+		return 0, fmt.Errorf("reservation not found: %w", err)
+	}
+
+	balance := -1
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.CreditTransaction{}).
+			Where("id = ? AND status = ?", reserved.ID, "reserved").
+			Update("status", "released")
+		if result.Error != nil {
+			return fmt.Errorf("failed to release reservation: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		var err error
+		balance, err = apply(tx, reserved.UserID, -reserved.Amount, "refund", referenceType, referenceID, reason)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if balance < 0 {
+		var user models.User
+		if err := database.DB.Select("credits").First(&user, reserved.UserID).Error; err != nil {
+			return 0, fmt.Errorf("failed to read balance: %w", err)
+		}
+		balance = user.Credits
+	}
+
+	return balance, nil
+}
+
+// ReleaseStaleReservations refunds search reservations left open longer than maxAge,
+// e.g. when the request handling the search timed out. Searches still queued or running
+// as jobs and saved search runs still running keep their reservation.
+func ReleaseStaleReservations(maxAge time.Duration) (int, error) {
+	var stale []models.CreditTransaction
+	err := database.DB.Where("type = ? AND status = ? AND created_at < ?", "debit", "reserved", time.Now().Add(-maxAge)).
+		Where("reference_type IN ?", []string{"search", "saved_search"}).
+		Where("NOT EXISTS (SELECT 1 FROM search_jobs WHERE search_jobs.search_id = credit_transactions.reference_id AND search_jobs.status IN ?)", []string{"queued", "running"}).
+		Where("NOT EXISTS (SELECT 1 FROM saved_search_runs WHERE saved_search_runs.search_id = credit_transactions.reference_id AND saved_search_runs.status = ?)", "running").
+		Find(&stale).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch stale reservations: %w", err)
+	}
+
+	released := 0
+	for _, reservation := range stale {
+		if _, err := ReleaseReservation(reservation.ReferenceType, reservation.ReferenceID, "Search did not complete"); err != nil {
+			log.Printf("Failed to release stale reservation %d: %v", reservation.ID, err)
+			continue
+		}
+		released++
+	}
+
+	return released, nil
+}
@@ -0,0 +1,208 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"medina-consultancy-api/pkg/outbound"
+	"medina-consultancy-api/pkg/places"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultUserAgent    = "MedinaConsultancyBot/1.0"
+	defaultMaxBodyBytes = 512 << 10
+)
+
+var (
+	ErrDisallowed     = errors.New("disallowed by robots.txt")
+	ErrInvalidWebsite = errors.New("invalid website URL")
+	ErrPrivateAddress = errors.New("website resolves to a private address")
+)
+
+// StatusError is a page the website answered with something other than 200.
+type StatusError struct {
+	Status int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("website returned HTTP %d", e.Status)
+}
+
+// Enricher visits place websites and extracts the contacts published on them: emails,
+// phones, social profiles and WhatsApp links. Only pages allowed by the robots.txt of the
+// site are fetched.
+type Enricher struct {
+	client    *outbound.Client
+	userAgent string
+
+	robotsMutex sync.Mutex
+	robots      map[string]*robotsRules // by origin
+}
+
+// Default is the enricher shared by every search of the process so that the rate limit
+// applies to all of them together.
+var Default = sync.OnceValue(NewFromEnv)
+
+// NewFromEnv reads the ENRICH_* outbound settings (see outbound.ConfigFromEnv),
+// ENRICH_MAX_BYTES, ENRICH_USER_AGENT and ENRICH_ALLOW_PRIVATE. Private and loopback
+// addresses are refused unless ENRICH_ALLOW_PRIVATE=true, which is meant for running
+// against a local fixture server.
+func NewFromEnv() *Enricher {
+	config := outbound.ConfigFromEnv("ENRICH", outbound.Config{
+		RequestsPerSecond: 20,
+		Burst:             20,
+		MaxConcurrent:     16,
+		Timeout:           8 * time.Second,
+		MaxRetries:        1,
+		Backoff:           500 * time.Millisecond,
+		MaxBodyBytes:      defaultMaxBodyBytes,
+	})
+	if value, err := strconv.ParseInt(os.Getenv("ENRICH_MAX_BYTES"), 10, 64); err == nil && value > 0 {
+		config.MaxBodyBytes = value
+	}
+	if os.Getenv("ENRICH_ALLOW_PRIVATE") != "true" {
+		config.Transport = publicTransport()
+	}
+
+	userAgent := os.Getenv("ENRICH_USER_AGENT")
+	if userAgent == "" {
+		userAgent = defaultUserAgent
+	}
+
+	return New(config, userAgent)
+}
+
+func New(config outbound.Config, userAgent string) *Enricher {
+	return &Enricher{
+		client:    outbound.NewClient(config),
+		userAgent: userAgent,
+		robots:    make(map[string]*robotsRules),
+	}
+}
+
+// Enrich fetches the home page of the place website, and its contact page when one is
+// linked, and fills the contact fields of the place.
+func (e *Enricher) Enrich(ctx context.Context, place *places.PlaceDetails) error {
+	site, err := websiteURL(place.Website)
+	if err != nil {
+		return err
+	}
+
+	home, err := e.fetch(ctx, site)
+	if err != nil {
+		return err
+	}
+
+	found := newContacts()
+	found.extract(home, site)
+
+	if contact := contactPage(home, site); contact != nil {
+		page, err := e.fetch(ctx, contact)
+		if err == nil {
+			found.extract(page, contact)
+		} else if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	found.apply(place)
+	return nil
+}
+
+func (e *Enricher) fetch(ctx context.Context, page *url.URL) (string, error) {
+	allowed, err := e.allowed(ctx, page)
+	if err != nil {
+		return "", err
+	}
+	if !allowed {
+		return "", ErrDisallowed
+	}
+
+	status, body, err := e.client.Get(ctx, page.String(), e.header(), outbound.RetryServerErrors)
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusOK {
+		return "", &StatusError{Status: status}
+	}
+
+	return string(body), nil
+}
+
+func (e *Enricher) header() http.Header {
+	return http.Header{
+		"User-Agent": {e.userAgent},
+		"Accept":     {"text/html,application/xhtml+xml;q=0.9,*/*;q=0.5"},
+	}
+}
+
+// ErrorStatus classifies an enrichment error for reporting.
+func ErrorStatus(err error) string {
+	var statusErr *StatusError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, ErrDisallowed):
+		return "ROBOTS_DISALLOWED"
+	case errors.Is(err, ErrInvalidWebsite):
+		return "INVALID_WEBSITE"
+	case errors.Is(err, ErrPrivateAddress):
+		return "PRIVATE_ADDRESS"
+	case errors.As(err, &statusErr):
+		return fmt.Sprintf("HTTP_%d", statusErr.Status)
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "TIMEOUT"
+	case errors.Is(err, context.Canceled):
+		return "CANCELED"
+	default:
+		return "FETCH_FAILED"
+	}
+}
+
+// websiteURL accepts the website as returned by the providers, which sometimes omit the
+// scheme.
+func websiteURL(website string) (*url.URL, error) {
+	website = strings.TrimSpace(website)
+	if !strings.Contains(website, "://") {
+		website = "http://" + website
+	}
+
+	site, err := url.Parse(website)
+	if err != nil || (site.Scheme != "http" && site.Scheme != "https") || site.Hostname() == "" {
+		return nil, ErrInvalidWebsite
+	}
+	return site, nil
+}
+
+// publicTransport refuses to connect to loopback, private and link-local addresses, so a
+// website (or a redirect) cannot point the enricher at internal services.
+func publicTransport() http.RoundTripper {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		ControlContext: func(_ context.Context, _, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+				ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+				return ErrPrivateAddress
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
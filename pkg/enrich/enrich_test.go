@@ -0,0 +1,192 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"medina-consultancy-api/pkg/outbound"
+	"medina-consultancy-api/pkg/places"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testConfig() outbound.Config {
+	return outbound.Config{
+		RequestsPerSecond: 100,
+		Burst:             100,
+		MaxConcurrent:     4,
+		Timeout:           2 * time.Second,
+		MaxBodyBytes:      defaultMaxBodyBytes,
+	}
+}
+
+// fixtureSite serves the given pages by path, robots.txt included, and 404 for the rest.
+func fixtureSite(t *testing.T, pages map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if strings.HasPrefix(page, "HTTP ") {
+			var status int
+			fmt.Sscanf(page, "HTTP %d", &status)
+			w.WriteHeader(status)
+			return
+		}
+		fmt.Fprint(w, page)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestEnrich(t *testing.T) {
+	server := fixtureSite(t, map[string]string{
+		"/robots.txt": "User-agent: *\nDisallow: /privado\n",
+		"/": `<html><body>
+			<a href="mailto:contato@loja.com.br">Email</a>
+			<a href="tel:(11) 3456-7890">Ligue</a>
+			<p>Celular (11) 91234-5678</p>
+			<a href="https://www.instagram.com/loja/">Instagram</a>
+			<a href="https://wa.me/5511912345678">WhatsApp</a>
+			<a href="/contato">Contato</a>
+		</body></html>`,
+		"/contato": `<p>Vendas: vendas@loja.com.br</p><a href="https://facebook.com/lojaoficial">Facebook</a>`,
+	})
+
+	place := places.PlaceDetails{Website: server.URL, FormattedPhoneNumber: "(11) 3456-7890"}
+	if err := New(testConfig(), "TestBot/1.0").Enrich(context.Background(), &place); err != nil {
+		t.Fatalf("Enrich: %v", err)
+	}
+
+	if want := []string{"contato@loja.com.br", "vendas@loja.com.br"}; !reflect.DeepEqual(place.Emails, want) {
+		t.Errorf("Emails = %v, want %v", place.Emails, want)
+	}
+	if want := []string{"+5511912345678"}; !reflect.DeepEqual(place.WebsitePhones, want) {
+		t.Errorf("WebsitePhones = %v, want %v", place.WebsitePhones, want)
+	}
+	wantSocial := map[string]string{"instagram": "https://instagram.com/loja", "facebook": "https://facebook.com/lojaoficial"}
+	if !reflect.DeepEqual(place.SocialLinks, wantSocial) {
+		t.Errorf("SocialLinks = %v, want %v", place.SocialLinks, wantSocial)
+	}
+	if place.WhatsApp != "https://wa.me/5511912345678" {
+		t.Errorf("WhatsApp = %q", place.WhatsApp)
+	}
+	if place.EnrichedAt == nil {
+		t.Error("EnrichedAt not set")
+	}
+}
+
+func TestEnrichRobots(t *testing.T) {
+	tests := []struct {
+		name    string
+		robots  string
+		wantErr error
+	}{
+		{"disallowed for the agent", "User-agent: testbot\nDisallow: /\n", ErrDisallowed},
+		{"disallowed for everyone", "User-agent: *\nDisallow: /\n", ErrDisallowed},
+		{"allowed for the agent", "User-agent: *\nDisallow: /\n\nUser-agent: testbot\nAllow: /\n", nil},
+		{"missing robots.txt", "", nil},
+		{"robots.txt failing", "HTTP 503", ErrDisallowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages := map[string]string{"/": `<a href="mailto:contato@loja.com.br">Email</a>`}
+			if tt.robots != "" {
+				pages["/robots.txt"] = tt.robots
+			}
+			server := fixtureSite(t, pages)
+
+			place := places.PlaceDetails{Website: server.URL}
+			err := New(testConfig(), "TestBot/1.0").Enrich(context.Background(), &place)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Enrich error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && len(place.Emails) != 1 {
+				t.Errorf("Emails = %v, want one", place.Emails)
+			}
+		})
+	}
+}
+
+func TestEnrichContactPageDisallowed(t *testing.T) {
+	server := fixtureSite(t, map[string]string{
+		"/robots.txt": "User-agent: *\nDisallow: /contato\n",
+		"/":           `<a href="/contato">Contato</a> contato@loja.com.br`,
+		"/contato":    `vendas@loja.com.br`,
+	})
+
+	place := places.PlaceDetails{Website: server.URL}
+	if err := New(testConfig(), "TestBot/1.0").Enrich(context.Background(), &place); err != nil {
+		t.Fatalf("Enrich: %v", err)
+	}
+	if want := []string{"contato@loja.com.br"}; !reflect.DeepEqual(place.Emails, want) {
+		t.Errorf("Emails = %v, want %v", place.Emails, want)
+	}
+}
+
+func TestEnrichHomePageStatus(t *testing.T) {
+	server := fixtureSite(t, map[string]string{"/robots.txt": "User-agent: *\nAllow: /\n"})
+
+	place := places.PlaceDetails{Website: server.URL}
+	err := New(testConfig(), "TestBot/1.0").Enrich(context.Background(), &place)
+	if status := ErrorStatus(err); status != "HTTP_404" {
+		t.Errorf("ErrorStatus = %q, want HTTP_404 (error %v)", status, err)
+	}
+}
+
+func TestEnrichBlocksPrivateAddresses(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, "contato@loja.com.br")
+	}))
+	defer server.Close()
+
+	config := testConfig()
+	config.Transport = publicTransport()
+
+	place := places.PlaceDetails{Website: server.URL}
+	err := New(config, "TestBot/1.0").Enrich(context.Background(), &place)
+	if !errors.Is(err, ErrPrivateAddress) {
+		t.Fatalf("Enrich error = %v, want %v", err, ErrPrivateAddress)
+	}
+	if status := ErrorStatus(err); status != "PRIVATE_ADDRESS" {
+		t.Errorf("ErrorStatus = %q, want PRIVATE_ADDRESS", status)
+	}
+	if requests > 0 {
+		t.Errorf("server got %d request(s), want none", requests)
+	}
+}
+
+func TestWebsiteURL(t *testing.T) {
+	tests := []struct {
+		website string
+		want    string
+		wantErr bool
+	}{
+		{"loja.com.br", "http://loja.com.br", false},
+		{" https://loja.com.br/inicio ", "https://loja.com.br/inicio", false},
+		{"ftp://loja.com.br", "", true},
+		{"http://", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		site, err := websiteURL(tt.website)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidWebsite) {
+				t.Errorf("websiteURL(%q) error = %v, want ErrInvalidWebsite", tt.website, err)
+			}
+			continue
+		}
+		if err != nil || site.String() != tt.want {
+			t.Errorf("websiteURL(%q) = %v, %v, want %q", tt.website, site, err, tt.want)
+		}
+	}
+}
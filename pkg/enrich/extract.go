@@ -0,0 +1,224 @@
+package enrich
+
+import (
+	"html"
+	"medina-consultancy-api/pkg/phone"
+	"medina-consultancy-api/pkg/places"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	maxEmails = 5
+	maxPhones = 5
+)
+
+var (
+	hrefPattern   = regexp.MustCompile(`(?i)href\s*=\s*["']([^"']+)["']`)
+	emailPattern  = regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9-]+(?:\.[a-zA-Z0-9-]+)*\.[a-zA-Z]{2,}`)
+	phonePattern  = regexp.MustCompile(`(?:\+55[\s.-]?)?\(?0?[1-9]{2}\)?[\s.-]?9?\d{4}[\s.-]?\d{4}`)
+	hiddenPattern = regexp.MustCompile(`(?is)<(?:script|style|noscript)[^>]*>.*?</(?:script|style|noscript)>`)
+	tagPattern    = regexp.MustCompile(`<[^>]*>`)
+)
+
+// socialNetworks maps the domains of the supported networks to their names.
+var socialNetworks = map[string]string{
+	"instagram.com": "instagram",
+	"facebook.com":  "facebook",
+	"fb.com":        "facebook",
+	"linkedin.com":  "linkedin",
+	"tiktok.com":    "tiktok",
+	"youtube.com":   "youtube",
+	"twitter.com":   "twitter",
+	"x.com":         "twitter",
+}
+
+// socialSkipped are first path segments of share buttons, embeds and posts, not profiles.
+var socialSkipped = []string{"sharer", "sharer.php", "share", "intent", "plugins", "dialog", "tr", "p", "reel", "watch", "embed", "hashtag", "home", "login"}
+
+// ignoredEmailDomains show up in page templates and error trackers, not as contacts.
+var ignoredEmailDomains = []string{"example.com", "sentry.io", "wixpress.com", "domain.com", "email.com"}
+
+var contactPageWords = []string{"contato", "contact", "fale-conosco", "faleconosco", "contacto"}
+
+type contacts struct {
+	emails   []string
+	phones   []string
+	social   map[string]string
+	whatsapp string
+}
+
+func newContacts() *contacts {
+	return &contacts{social: make(map[string]string)}
+}
+
+// extract collects the contacts of an HTML page: links first (mailto, tel, WhatsApp and
+// social profiles), then emails and phones written in the text.
+func (c *contacts) extract(page string, base *url.URL) {
+	for _, match := range hrefPattern.FindAllStringSubmatch(page, -1) {
+		c.addLink(html.UnescapeString(match[1]), base)
+	}
+
+	for _, email := range emailPattern.FindAllString(html.UnescapeString(page), -1) {
+		c.addEmail(email)
+	}
+
+	text := html.UnescapeString(tagPattern.ReplaceAllString(hiddenPattern.ReplaceAllString(page, " "), " "))
+	for _, loc := range phonePattern.FindAllStringIndex(text, -1) {
+		// skip digits that are part of longer numbers such as IDs and prices
+		if loc[0] > 0 && isDigit(text[loc[0]-1]) || loc[1] < len(text) && isDigit(text[loc[1]]) {
+			continue
+		}
+		c.addPhone(text[loc[0]:loc[1]])
+	}
+}
+
+func (c *contacts) addLink(href string, base *url.URL) {
+	href = strings.TrimSpace(href)
+	lower := strings.ToLower(href)
+
+	switch {
+	case strings.HasPrefix(lower, "mailto:"):
+		address, _, _ := strings.Cut(href[len("mailto:"):], "?")
+		if unescaped, err := url.PathUnescape(address); err == nil {
+			address = unescaped
+		}
+		c.addEmail(address)
+		return
+	case strings.HasPrefix(lower, "tel:"):
+		c.addPhone(href[len("tel:"):])
+		return
+	}
+
+	link, err := base.Parse(href)
+	if err != nil || (link.Scheme != "http" && link.Scheme != "https") {
+		return
+	}
+	host := strings.ToLower(link.Hostname())
+
+	if number := whatsAppNumber(host, link); number != "" {
+		if c.whatsapp == "" {
+			c.whatsapp = "https://wa.me/" + number
+		}
+		return
+	}
+
+	for domain, network := range socialNetworks {
+		if host != domain && !strings.HasSuffix(host, "."+domain) {
+			continue
+		}
+		path := strings.TrimSuffix(link.EscapedPath(), "/")
+		segment, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+		if segment == "" || slices.Contains(socialSkipped, strings.ToLower(segment)) {
+			return
+		}
+		if _, exists := c.social[network]; !exists {
+			c.social[network] = "https://" + domain + path
+		}
+		return
+	}
+}
+
+// whatsAppNumber reads the number of wa.me and api.whatsapp.com/send?phone= links.
+func whatsAppNumber(host string, link *url.URL) string {
+	switch {
+	case host == "wa.me":
+		return digitsPhone(strings.Trim(link.Path, "/"))
+	case host == "whatsapp.com" || strings.HasSuffix(host, ".whatsapp.com"):
+		return digitsPhone(link.Query().Get("phone"))
+	}
+	return ""
+}
+
+func (c *contacts) addEmail(email string) {
+	email = strings.ToLower(strings.Trim(strings.TrimSpace(email), "."))
+	if len(c.emails) >= maxEmails || !emailPattern.MatchString(email) || slices.Contains(c.emails, email) {
+		return
+	}
+
+	_, domain, _ := strings.Cut(email, "@")
+	// image names such as logo@2x.png look like addresses
+	for _, extension := range []string{".png", ".jpg", ".jpeg", ".gif", ".webp", ".svg"} {
+		if strings.HasSuffix(domain, extension) {
+			return
+		}
+	}
+	for _, ignored := range ignoredEmailDomains {
+		if domain == ignored || strings.HasSuffix(domain, "."+ignored) {
+			return
+		}
+	}
+
+	c.emails = append(c.emails, email)
+}
+
+func (c *contacts) addPhone(raw string) {
+	number := phone.E164(raw)
+	if number == "" || len(c.phones) >= maxPhones || slices.Contains(c.phones, number) {
+		return
+	}
+	c.phones = append(c.phones, number)
+}
+
+// apply sets the contact fields of the place. Phones already known from the provider are
+// not repeated.
+func (c *contacts) apply(place *places.PlaceDetails) {
+	known := phone.E164(place.FormattedPhoneNumber)
+
+	var phones []string
+	for _, number := range c.phones {
+		if number != known {
+			phones = append(phones, number)
+		}
+	}
+
+	now := time.Now()
+	place.Emails = c.emails
+	place.WebsitePhones = phones
+	place.SocialLinks = nil
+	if len(c.social) > 0 {
+		place.SocialLinks = c.social
+	}
+	place.WhatsApp = c.whatsapp
+	place.EnrichedAt = &now
+}
+
+// contactPage finds a link to a contact page on the same site.
+func contactPage(page string, site *url.URL) *url.URL {
+	siteHost := strings.TrimPrefix(strings.ToLower(site.Hostname()), "www.")
+
+	for _, match := range hrefPattern.FindAllStringSubmatch(page, -1) {
+		href := strings.ToLower(html.UnescapeString(match[1]))
+		if !slices.ContainsFunc(contactPageWords, func(word string) bool { return strings.Contains(href, word) }) {
+			continue
+		}
+
+		link, err := site.Parse(html.UnescapeString(match[1]))
+		if err != nil || (link.Scheme != "http" && link.Scheme != "https") {
+			continue
+		}
+		if strings.TrimPrefix(strings.ToLower(link.Hostname()), "www.") != siteHost {
+			continue
+		}
+
+		link.Fragment = ""
+		if link.String() == site.String() {
+			continue
+		}
+		return link
+	}
+
+	return nil
+}
+
+// digitsPhone normalizes a number to the digits used by wa.me links.
+func digitsPhone(raw string) string {
+	return strings.TrimPrefix(phone.E164(raw), "+")
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
@@ -0,0 +1,137 @@
+package enrich
+
+import (
+	"medina-consultancy-api/pkg/places"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	base, _ := url.Parse("https://www.loja.com.br/")
+
+	tests := []struct {
+		name     string
+		page     string
+		emails   []string
+		phones   []string
+		social   map[string]string
+		whatsapp string
+	}{
+		{
+			name:   "mailto and tel links",
+			page:   `<a href="mailto:Contato@Loja.com.br?subject=Oi">Email</a> <a href="tel:+55 11 3456-7890">Ligue</a>`,
+			emails: []string{"contato@loja.com.br"},
+			phones: []string{"+551134567890"},
+		},
+		{
+			name:   "emails and phones in the text",
+			page:   `<p>Fale com vendas@loja.com.br ou (11) 91234-5678</p>`,
+			emails: []string{"vendas@loja.com.br"},
+			phones: []string{"+5511912345678"},
+		},
+		{
+			name: "ignored emails",
+			page: `<img src="logo@2x.png"> user@example.com erros@o123.ingest.sentry.io`,
+		},
+		{
+			name: "digits of longer numbers and scripts",
+			page: `<p>Pedido 1134567890123</p><script>var tel = "(11) 3456-7890";</script>`,
+		},
+		{
+			name: "social profiles",
+			page: `<a href="https://www.instagram.com/loja/">IG</a>
+				<a href="https://www.facebook.com/sharer/sharer.php?u=x">Compartilhar</a>
+				<a href="https://facebook.com/lojaoficial">FB</a>
+				<a href="https://instagram.com/outra">IG 2</a>`,
+			social: map[string]string{
+				"instagram": "https://instagram.com/loja",
+				"facebook":  "https://facebook.com/lojaoficial",
+			},
+		},
+		{
+			name:     "whatsapp send link",
+			page:     `<a href="https://api.whatsapp.com/send?phone=5511912345678&amp;text=Oi">WhatsApp</a>`,
+			whatsapp: "https://wa.me/5511912345678",
+		},
+		{
+			name:     "wa.me link keeps the first",
+			page:     `<a href="https://wa.me/11912345678">1</a><a href="https://wa.me/5521987654321">2</a>`,
+			whatsapp: "https://wa.me/5511912345678",
+		},
+		{
+			name:   "emails are capped",
+			page:   `a@loja.com b@loja.com c@loja.com d@loja.com e@loja.com f@loja.com`,
+			emails: []string{"a@loja.com", "b@loja.com", "c@loja.com", "d@loja.com", "e@loja.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found := newContacts()
+			found.extract(tt.page, base)
+
+			if !reflect.DeepEqual(found.emails, tt.emails) {
+				t.Errorf("emails = %v, want %v", found.emails, tt.emails)
+			}
+			if !reflect.DeepEqual(found.phones, tt.phones) {
+				t.Errorf("phones = %v, want %v", found.phones, tt.phones)
+			}
+			if tt.social == nil {
+				tt.social = map[string]string{}
+			}
+			if !reflect.DeepEqual(found.social, tt.social) {
+				t.Errorf("social = %v, want %v", found.social, tt.social)
+			}
+			if found.whatsapp != tt.whatsapp {
+				t.Errorf("whatsapp = %q, want %q", found.whatsapp, tt.whatsapp)
+			}
+		})
+	}
+}
+
+func TestApplySkipsKnownPhone(t *testing.T) {
+	found := newContacts()
+	found.phones = []string{"+551134567890", "+5511912345678"}
+
+	place := places.PlaceDetails{FormattedPhoneNumber: "(11) 3456-7890"}
+	found.apply(&place)
+
+	if want := []string{"+5511912345678"}; !reflect.DeepEqual(place.WebsitePhones, want) {
+		t.Errorf("WebsitePhones = %v, want %v", place.WebsitePhones, want)
+	}
+	if place.SocialLinks != nil {
+		t.Errorf("SocialLinks = %v, want nil", place.SocialLinks)
+	}
+	if place.EnrichedAt == nil {
+		t.Error("EnrichedAt not set")
+	}
+}
+
+func TestContactPage(t *testing.T) {
+	site, _ := url.Parse("https://loja.com.br")
+
+	tests := []struct {
+		name string
+		page string
+		want string
+	}{
+		{"relative link", `<a href="/fale-conosco#form">Fale conosco</a>`, "https://loja.com.br/fale-conosco"},
+		{"www of the same site", `<a href="https://www.loja.com.br/contato">Contato</a>`, "https://www.loja.com.br/contato"},
+		{"other site", `<a href="https://outra.com.br/contato">Contato</a>`, ""},
+		{"mailto", `<a href="mailto:contato@loja.com.br">Contato</a>`, ""},
+		{"no contact link", `<a href="/produtos">Produtos</a>`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if link := contactPage(tt.page, site); link != nil {
+				got = link.String()
+			}
+			if got != tt.want {
+				t.Errorf("contactPage = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package enrich
+
+import (
+	"context"
+	"medina-consultancy-api/pkg/outbound"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// robotsTTL is how long the robots.txt of a site is trusted before being fetched again.
+const robotsTTL = 24 * time.Hour
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+type robotsRules struct {
+	rules     []robotsRule
+	fetchedAt time.Time
+}
+
+// allowed tells whether the robots.txt of the site lets the enricher fetch the page.
+func (e *Enricher) allowed(ctx context.Context, page *url.URL) (bool, error) {
+	origin := page.Scheme + "://" + page.Host
+
+	e.robotsMutex.Lock()
+	rules, ok := e.robots[origin]
+	e.robotsMutex.Unlock()
+
+	if !ok || time.Since(rules.fetchedAt) > robotsTTL {
+		var err error
+		if rules, err = e.fetchRobots(ctx, origin); err != nil {
+			return false, err
+		}
+
+		e.robotsMutex.Lock()
+		e.robots[origin] = rules
+		e.robotsMutex.Unlock()
+	}
+
+	path := page.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if page.RawQuery != "" {
+		path += "?" + page.RawQuery
+	}
+
+	return rules.allows(path), nil
+}
+
+// fetchRobots follows RFC 9309: a missing robots.txt allows everything and a site that
+// fails to serve it is treated as fully disallowed.
+func (e *Enricher) fetchRobots(ctx context.Context, origin string) (*robotsRules, error) {
+	status, body, err := e.client.Get(ctx, origin+"/robots.txt", e.header(), outbound.RetryServerErrors)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case status >= 200 && status < 300:
+		return parseRobots(string(body), robotsAgent(e.userAgent)), nil
+	case status >= 400 && status < 500:
+		return &robotsRules{fetchedAt: time.Now()}, nil
+	default:
+		return &robotsRules{rules: []robotsRule{{pattern: "/"}}, fetchedAt: time.Now()}, nil
+	}
+}
+
+// robotsAgent is the product token matched against User-agent lines, "examplebot" for
+// "ExampleBot/1.0".
+func robotsAgent(userAgent string) string {
+	token, _, _ := strings.Cut(userAgent, "/")
+	return strings.ToLower(strings.TrimSpace(token))
+}
+
+// parseRobots keeps the rules of the groups naming the agent, or of the "*" groups when
+// none does.
+func parseRobots(data string, agent string) *robotsRules {
+	var specific, wildcard []robotsRule
+	var groupAgents []string
+	matched := false
+	inRules := false
+
+	for _, line := range strings.Split(data, "\n") {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// a user-agent line after rules starts a new group
+			if inRules {
+				groupAgents = nil
+				inRules = false
+			}
+			value = strings.ToLower(value)
+			groupAgents = append(groupAgents, value)
+			if value == agent {
+				matched = true
+			}
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				continue // an empty disallow allows everything
+			}
+			rule := robotsRule{allow: key == "allow", pattern: value}
+			for _, groupAgent := range groupAgents {
+				switch groupAgent {
+				case agent:
+					specific = append(specific, rule)
+				case "*":
+					wildcard = append(wildcard, rule)
+				}
+			}
+		}
+	}
+
+	rules := &robotsRules{rules: wildcard, fetchedAt: time.Now()}
+	if matched {
+		rules.rules = specific
+	}
+	return rules
+}
+
+// allows applies the most specific (longest) matching rule, allow winning ties.
+func (r *robotsRules) allows(path string) bool {
+	allowed := true
+	longest := -1
+
+	for _, rule := range r.rules {
+		if !robotsMatch(rule.pattern, path) {
+			continue
+		}
+		if len(rule.pattern) > longest || (len(rule.pattern) == longest && rule.allow) {
+			allowed = rule.allow
+			longest = len(rule.pattern)
+		}
+	}
+
+	return allowed
+}
+
+// robotsMatch matches a path against a robots.txt pattern, where * matches any sequence
+// and a trailing $ anchors the end of the path.
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	parts := strings.Split(strings.TrimSuffix(pattern, "$"), "*")
+
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	if len(parts) == 1 {
+		return !anchored || rest == ""
+	}
+
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+
+	last := parts[len(parts)-1]
+	if anchored {
+		return strings.HasSuffix(rest, last)
+	}
+	return strings.Contains(rest, last)
+}
@@ -0,0 +1,61 @@
+package enrich
+
+import "testing"
+
+const testRobots = `# rules for everyone
+User-agent: *
+Disallow: /
+
+User-agent: TestBot
+User-agent: otherbot
+Disallow: /admin
+Allow: /admin/public
+Disallow: /*.pdf$
+Disallow: /tmp/*/cache # build output
+`
+
+func TestParseRobots(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		agent string
+		path  string
+		want  bool
+	}{
+		{"named group root", testRobots, "testbot", "/", true},
+		{"disallowed prefix", testRobots, "testbot", "/admin/users", false},
+		{"longer allow wins", testRobots, "testbot", "/admin/public/logo.png", true},
+		{"anchored pattern", testRobots, "testbot", "/files/menu.pdf", false},
+		{"anchored pattern with query", testRobots, "testbot", "/files/menu.pdf?v=2", true},
+		{"wildcard in the middle", testRobots, "testbot", "/tmp/2024/cache/page", false},
+		{"wildcard not matching", testRobots, "testbot", "/tmp/cache", true},
+		{"second agent of the group", testRobots, "otherbot", "/admin", false},
+		{"falls back to star group", testRobots, "somebot", "/contato", false},
+		{"empty disallow allows everything", "User-agent: *\nDisallow:\n", "testbot", "/admin", true},
+		{"no robots rules", "", "testbot", "/admin", true},
+		{"user-agent after rules starts a group", "User-agent: testbot\nDisallow: /a\nUser-agent: *\nDisallow: /b\n", "testbot", "/b", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := parseRobots(tt.data, tt.agent)
+			if got := rules.allows(tt.path); got != tt.want {
+				t.Errorf("allows(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRobotsAgent(t *testing.T) {
+	tests := map[string]string{
+		"MedinaConsultancyBot/1.0": "medinaconsultancybot",
+		"TestBot":                  "testbot",
+		" Spaced /2":               "spaced",
+	}
+
+	for userAgent, want := range tests {
+		if got := robotsAgent(userAgent); got != want {
+			t.Errorf("robotsAgent(%q) = %q, want %q", userAgent, got, want)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package export
+
+import (
+	"fmt"
+	"medina-consultancy-api/pkg/phone"
+	"medina-consultancy-api/pkg/places"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	LanguagePortuguese = "pt-BR"
+	LanguageEnglish    = "en"
+	LanguageSpanish    = "es"
+)
+
+// column is one field of the tabular exports (CSV and XLSX).
+type column struct {
+	id      string
+	headers map[string]string // by language
+	value   func(places.PlaceDetails) string
+}
+
+var DefaultColumns = []string{"name", "address", "phone", "phone_e164", "phone_type", "whatsapp", "website"}
+
+// EnrichmentColumns are added to the default columns of searches that visited the place
+// websites.
+var EnrichmentColumns = []string{"emails", "website_phones", "instagram", "facebook", "linkedin"}
+
+var columns = []column{
+	{"place_id", headers("ID do Local", "Place ID", "ID del Lugar"), func(p places.PlaceDetails) string { return p.PlaceID }},
+	{"name", headers("Nome", "Name", "Nombre"), func(p places.PlaceDetails) string { return p.Name }},
+	{"address", headers("Endereço", "Address", "Dirección"), func(p places.PlaceDetails) string { return p.FormattedAddress }},
+	{"phone", headers("Telefone", "Phone", "Teléfono"), func(p places.PlaceDetails) string { return p.FormattedPhoneNumber }},
+	{"phone_e164", headers("Telefone (E.164)", "Phone (E.164)", "Teléfono (E.164)"), func(p places.PlaceDetails) string { return p.PhoneE164 }},
+	{"phone_type", headers("Tipo de Telefone", "Phone Type", "Tipo de Teléfono"), func(p places.PlaceDetails) string { return p.PhoneType }},
+	{"whatsapp_likely", headers("Provável WhatsApp", "Likely WhatsApp", "Probable WhatsApp"), func(p places.PlaceDetails) string { return strconv.FormatBool(p.WhatsAppLikely) }},
+	{"website", headers("Website", "Website", "Sitio Web"), func(p places.PlaceDetails) string { return p.Website }},
+	{"url", headers("URL", "URL", "URL"), func(p places.PlaceDetails) string { return p.URL }},
+	{"maps_url", headers("Google Maps", "Google Maps", "Google Maps"), mapsURL},
+	{"lat", headers("Latitude", "Latitude", "Latitud"), func(p places.PlaceDetails) string { return formatCoordinate(p.Lat) }},
+	{"lng", headers("Longitude", "Longitude", "Longitud"), func(p places.PlaceDetails) string { return formatCoordinate(p.Lng) }},
+	{"rating", headers("Avaliação", "Rating", "Calificación"), func(p places.PlaceDetails) string { return formatOptional(p.Rating) }},
+	{"reviews", headers("Avaliações", "Reviews", "Reseñas"), func(p places.PlaceDetails) string { return formatOptional(float64(p.UserRatingsTotal)) }},
+	{"price_level", headers("Nível de Preço", "Price Level", "Nivel de Precio"), func(p places.PlaceDetails) string { return formatOptional(float64(p.PriceLevel)) }},
+	{"business_status", headers("Situação", "Business Status", "Estado"), func(p places.PlaceDetails) string { return p.BusinessStatus }},
+	{"types", headers("Categorias", "Types", "Categorías"), func(p places.PlaceDetails) string { return strings.Join(p.Types, ", ") }},
+	{"open_now", headers("Aberto Agora", "Open Now", "Abierto Ahora"), openNow},
+	{"opening_hours", headers("Horário de Funcionamento", "Opening Hours", "Horario"), openingHours},
+	{"emails", headers("E-mails", "Emails", "Correos"), func(p places.PlaceDetails) string { return strings.Join(p.Emails, ", ") }},
+	{"website_phones", headers("Telefones do Site", "Website Phones", "Teléfonos del Sitio"), func(p places.PlaceDetails) string { return strings.Join(p.WebsitePhones, ", ") }},
+	{"whatsapp", headers("WhatsApp", "WhatsApp", "WhatsApp"), whatsApp},
+	{"instagram", headers("Instagram", "Instagram", "Instagram"), socialLink("instagram")},
+	{"facebook", headers("Facebook", "Facebook", "Facebook"), socialLink("facebook")},
+	{"linkedin", headers("LinkedIn", "LinkedIn", "LinkedIn"), socialLink("linkedin")},
+	{"tiktok", headers("TikTok", "TikTok", "TikTok"), socialLink("tiktok")},
+	{"youtube", headers("YouTube", "YouTube", "YouTube"), socialLink("youtube")},
+	{"twitter", headers("X (Twitter)", "X (Twitter)", "X (Twitter)"), socialLink("twitter")},
+}
+
+func headers(pt, en, es string) map[string]string {
+	return map[string]string{LanguagePortuguese: pt, LanguageEnglish: en, LanguageSpanish: es}
+}
+
+func lookupColumn(id string) (column, bool) {
+	for _, col := range columns {
+		if col.id == id {
+			return col, true
+		}
+	}
+	return column{}, false
+}
+
+// defaultColumnsFor adds the enrichment columns when any of the results was enriched.
+func defaultColumnsFor(results []places.PlaceDetails) []string {
+	for _, place := range results {
+		if place.EnrichedAt != nil {
+			return append(slices.Clone(DefaultColumns), EnrichmentColumns...)
+		}
+	}
+	return DefaultColumns
+}
+
+// ColumnIDs lists the columns that can be selected for tabular exports.
+func ColumnIDs() []string {
+	ids := make([]string, len(columns))
+	for i, col := range columns {
+		ids[i] = col.id
+	}
+	return ids
+}
+
+// mapsURL prefers the URL returned by the provider and falls back to a Google Maps
+// search on the coordinates.
+func mapsURL(p places.PlaceDetails) string {
+	if strings.Contains(p.URL, "google.com/maps") || strings.Contains(p.URL, "maps.google.com") {
+		return p.URL
+	}
+	if p.Lat == 0 && p.Lng == 0 {
+		return ""
+	}
+
+	query := url.Values{}
+	query.Set("api", "1")
+	query.Set("query", formatCoordinate(p.Lat)+","+formatCoordinate(p.Lng))
+	return "https://www.google.com/maps/search/?" + query.Encode()
+}
+
+// whatsApp prefers the link published on the website and falls back to the phone when
+// it is likely on WhatsApp.
+func whatsApp(p places.PlaceDetails) string {
+	if p.WhatsApp != "" {
+		return p.WhatsApp
+	}
+	if p.WhatsAppLikely {
+		return phone.WhatsAppLink(p.PhoneE164)
+	}
+	return ""
+}
+
+func socialLink(network string) func(places.PlaceDetails) string {
+	return func(p places.PlaceDetails) string {
+		return p.SocialLinks[network]
+	}
+}
+
+func openNow(p places.PlaceDetails) string {
+	if p.OpeningHours == nil {
+		return ""
+	}
+	return strconv.FormatBool(p.OpeningHours.OpenNow)
+}
+
+func openingHours(p places.PlaceDetails) string {
+	if p.OpeningHours == nil {
+		return ""
+	}
+	return strings.Join(p.OpeningHours.WeekdayText, " | ")
+}
+
+func formatCoordinate(value float64) string {
+	if value == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// formatOptional leaves zero values empty since providers use them for "unknown".
+func formatOptional(value float64) string {
+	if value == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func unknownColumnError(id string) error {
+	return fmt.Errorf("unknown column %q, available columns: %s", id, strings.Join(ColumnIDs(), ", "))
+}
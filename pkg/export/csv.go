@@ -0,0 +1,79 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"medina-consultancy-api/pkg/places"
+	"strings"
+)
+
+const utf8BOM = "\xEF\xBB\xBF"
+
+// CSV writes the results with a UTF-8 BOM so Excel detects the encoding. Options must be
+// normalized, use Format.Render otherwise.
+func CSV(results []places.PlaceDetails, opts Options) ([]byte, error) {
+	return writeCSV(opts.table(results), opts.delimiter())
+}
+
+func writeCSV(rows [][]string, delimiter rune) ([]byte, error) {
+	var buf strings.Builder
+
+	buf.WriteString(utf8BOM)
+
+	writer := csv.NewWriter(&buf)
+	writer.Comma = delimiter
+
+	for _, row := range rows {
+		if err := writer.Write(row); err != nil {
+			return nil, fmt.Errorf("failed to write CSV row: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("CSV writer error: %w", err)
+	}
+
+	return []byte(buf.String()), nil
+}
+
+// ParseCSV reads back a CSV written with the default options, for searches stored only
+// as a file.
+func ParseCSV(data []byte) ([]places.PlaceDetails, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte(utf8BOM))))
+	reader.Comma = ';'
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	index := map[string]int{}
+	for i, header := range records[0] {
+		index[header] = i
+	}
+
+	field := func(record []string, header string) string {
+		if i, ok := index[header]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	results := make([]places.PlaceDetails, 0, len(records)-1)
+	for _, record := range records[1:] {
+		results = append(results, places.PlaceDetails{
+			Name:                 field(record, "Nome"),
+			FormattedAddress:     field(record, "Endereço"),
+			FormattedPhoneNumber: field(record, "Telefone"),
+			Website:              field(record, "Website"),
+		})
+	}
+
+	return results, nil
+}
@@ -0,0 +1,50 @@
+package export
+
+import (
+	"medina-consultancy-api/models"
+	"strconv"
+)
+
+var diffHeaders = map[string][]string{
+	LanguagePortuguese: {"Mudança", "ID do Local", "Nome", "Endereço", "Telefone", "Website", "Situação", "Não Encontrado", "Telefone Anterior", "Website Anterior"},
+	LanguageEnglish:    {"Change", "Place ID", "Name", "Address", "Phone", "Website", "Business Status", "Missing", "Previous Phone", "Previous Website"},
+	LanguageSpanish:    {"Cambio", "ID del Lugar", "Nombre", "Dirección", "Teléfono", "Sitio Web", "Estado", "No Encontrado", "Teléfono Anterior", "Sitio Web Anterior"},
+}
+
+// Diff renders the changes found by a saved search run as csv, xlsx or json. Columns and
+// phone formatting do not apply, the delimiter and language do.
+func Diff(changes []models.PlaceChange, format Format, opts Options) ([]byte, error) {
+	opts, err := opts.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	if format.Extension == "json" {
+		if changes == nil {
+			changes = []models.PlaceChange{}
+		}
+		return indentedJSON(changes)
+	}
+
+	return renderTable(format, diffTable(changes, opts.Language), opts)
+}
+
+func diffTable(changes []models.PlaceChange, language string) [][]string {
+	rows := make([][]string, 0, len(changes)+1)
+	rows = append(rows, diffHeaders[language])
+	for _, change := range changes {
+		rows = append(rows, []string{
+			change.Change,
+			change.PlaceID,
+			change.Name,
+			change.Address,
+			change.Phone,
+			change.Website,
+			change.BusinessStatus,
+			strconv.FormatBool(change.Missing),
+			change.PreviousPhone,
+			change.PreviousWebsite,
+		})
+	}
+	return rows
+}
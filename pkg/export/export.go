@@ -0,0 +1,63 @@
+package export
+
+import (
+	"fmt"
+	"medina-consultancy-api/pkg/places"
+	"strings"
+)
+
+type Format struct {
+	ContentType string
+	Extension   string
+	render      func([]places.PlaceDetails, Options) ([]byte, error)
+}
+
+var Formats = map[string]Format{
+	"csv":    {ContentType: "text/csv", Extension: "csv", render: CSV},
+	"xlsx":   {ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", Extension: "xlsx", render: XLSX},
+	"json":   {ContentType: "application/json", Extension: "json", render: withoutOptions(JSON)},
+	"ndjson": {ContentType: "application/x-ndjson", Extension: "ndjson", render: withoutOptions(NDJSON)},
+	"vcf":    {ContentType: "text/vcard", Extension: "vcf", render: withoutOptions(VCard)},
+}
+
+func withoutOptions(render func([]places.PlaceDetails) ([]byte, error)) func([]places.PlaceDetails, Options) ([]byte, error) {
+	return func(results []places.PlaceDetails, _ Options) ([]byte, error) {
+		return render(results)
+	}
+}
+
+// LookupFormat resolves a format name such as "xlsx"; "vcard" is accepted for "vcf".
+func LookupFormat(name string) (Format, error) {
+	name = strings.ToLower(name)
+	if name == "vcard" {
+		name = "vcf"
+	}
+
+	format, ok := Formats[name]
+	if !ok {
+		return Format{}, fmt.Errorf("unsupported export format %q", name)
+	}
+
+	return format, nil
+}
+
+func (f Format) Render(results []places.PlaceDetails, opts Options) ([]byte, error) {
+	opts, err := opts.Normalize()
+	if err != nil {
+		return nil, err
+	}
+	return f.render(applyPhoneFormat(results, opts), opts)
+}
+
+// renderTable writes rows that are not places, such as diffs and leads, in the tabular
+// formats. Options must be normalized.
+func renderTable(format Format, rows [][]string, opts Options) ([]byte, error) {
+	switch format.Extension {
+	case "csv":
+		return writeCSV(rows, opts.delimiter())
+	case "xlsx":
+		return writeXLSX(rows)
+	default:
+		return nil, fmt.Errorf("this export is only available as csv, xlsx or json")
+	}
+}
@@ -0,0 +1,39 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"medina-consultancy-api/pkg/places"
+)
+
+func JSON(results []places.PlaceDetails) ([]byte, error) {
+	if results == nil {
+		results = []places.PlaceDetails{}
+	}
+
+	return indentedJSON(results)
+}
+
+func indentedJSON(value interface{}) ([]byte, error) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode JSON: %w", err)
+	}
+
+	return data, nil
+}
+
+// NDJSON writes one JSON object per line.
+func NDJSON(results []places.PlaceDetails) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+
+	for _, place := range results {
+		if err := encoder.Encode(place); err != nil {
+			return nil, fmt.Errorf("failed to encode JSON line: %w", err)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
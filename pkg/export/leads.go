@@ -0,0 +1,53 @@
+package export
+
+import (
+	"medina-consultancy-api/pkg/places"
+	"strings"
+)
+
+// Lead is a place with the sales information a user keeps about it.
+type Lead struct {
+	places.PlaceDetails
+	Status   string   `json:"status"`
+	Tags     []string `json:"tags"`
+	Lists    []string `json:"lists"`
+	LastNote string   `json:"last_note,omitempty"`
+}
+
+var leadHeaders = map[string][]string{
+	LanguagePortuguese: {"Status", "Tags", "Listas", "Última Nota"},
+	LanguageEnglish:    {"Status", "Tags", "Lists", "Last Note"},
+	LanguageSpanish:    {"Estado", "Etiquetas", "Listas", "Última Nota"},
+}
+
+// Leads renders leads as csv, xlsx or json: the place columns selected in the options
+// followed by the status, tags, lists and last note of each lead.
+func Leads(leads []Lead, format Format, opts Options) ([]byte, error) {
+	opts, err := opts.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]places.PlaceDetails, len(leads))
+	for i, lead := range leads {
+		results[i] = lead.PlaceDetails
+	}
+	results = applyPhoneFormat(results, opts)
+
+	if format.Extension == "json" {
+		formatted := make([]Lead, len(leads))
+		for i, lead := range leads {
+			lead.PlaceDetails = results[i]
+			formatted[i] = lead
+		}
+		return indentedJSON(formatted)
+	}
+
+	rows := opts.table(results)
+	rows[0] = append(rows[0], leadHeaders[opts.Language]...)
+	for i, lead := range leads {
+		rows[i+1] = append(rows[i+1], lead.Status, strings.Join(lead.Tags, ", "), strings.Join(lead.Lists, ", "), lead.LastNote)
+	}
+
+	return renderTable(format, rows, opts)
+}
@@ -0,0 +1,132 @@
+package export
+
+import (
+	"fmt"
+	"medina-consultancy-api/pkg/phone"
+	"medina-consultancy-api/pkg/places"
+	"strings"
+)
+
+// Options customize an export. Columns, Delimiter and Language only apply to the tabular
+// formats, E164 applies to all of them.
+type Options struct {
+	Columns   []string `json:"columns"`   // empty for the default columns of the results
+	Delimiter string   `json:"delimiter"` // ";" (default), ",", "|" or "tab"
+	Language  string   `json:"language"`  // pt-BR (default), en or es
+	E164      bool     `json:"e164"`
+}
+
+func DefaultOptions() Options {
+	return Options{Delimiter: ";", Language: LanguagePortuguese}
+}
+
+// Normalize fills the defaults and validates the options. Columns are left empty when
+// none were selected, see defaultColumnsFor.
+func (o Options) Normalize() (Options, error) {
+	selected := o.Columns
+	o.Columns = make([]string, len(selected))
+	for i, id := range selected {
+		id = strings.ToLower(strings.TrimSpace(id))
+		if _, ok := lookupColumn(id); !ok {
+			return o, unknownColumnError(id)
+		}
+		o.Columns[i] = id
+	}
+
+	switch o.Delimiter {
+	case "":
+		o.Delimiter = ";"
+	case ";", ",", "|":
+	case "tab", "\t":
+		o.Delimiter = "tab"
+	default:
+		return o, fmt.Errorf("unsupported delimiter %q, use ; , | or tab", o.Delimiter)
+	}
+
+	switch strings.ToLower(o.Language) {
+	case "", "pt", "pt-br":
+		o.Language = LanguagePortuguese
+	case "en", "en-us":
+		o.Language = LanguageEnglish
+	case "es", "es-es":
+		o.Language = LanguageSpanish
+	default:
+		return o, fmt.Errorf("unsupported language %q, use pt-BR, en or es", o.Language)
+	}
+
+	return o, nil
+}
+
+func (o Options) delimiter() rune {
+	if o.Delimiter == "tab" {
+		return '\t'
+	}
+	return rune(o.Delimiter[0])
+}
+
+// table renders the header and one row per place. Options must be normalized.
+func (o Options) table(results []places.PlaceDetails) [][]string {
+	ids := o.Columns
+	if len(ids) == 0 {
+		ids = defaultColumnsFor(results)
+	}
+
+	selected := make([]column, len(ids))
+	header := make([]string, len(ids))
+	for i, id := range ids {
+		selected[i], _ = lookupColumn(id)
+		header[i] = selected[i].headers[o.Language]
+	}
+
+	rows := make([][]string, 0, len(results)+1)
+	rows = append(rows, header)
+	for _, place := range results {
+		row := make([]string, len(selected))
+		for i, col := range selected {
+			row[i] = col.value(place)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// Records renders the selected columns as objects keyed by the translated headers, for
+// JSON responses.
+func Records(results []places.PlaceDetails, opts Options) ([]map[string]string, error) {
+	opts, err := opts.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	table := opts.table(applyPhoneFormat(results, opts))
+	records := make([]map[string]string, 0, len(table)-1)
+	for _, row := range table[1:] {
+		record := make(map[string]string, len(row))
+		for i, value := range row {
+			record[table[0][i]] = value
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// applyPhoneFormat returns a copy of the results with phones in E.164 when requested.
+// Numbers that cannot be normalized are kept as they are.
+func applyPhoneFormat(results []places.PlaceDetails, opts Options) []places.PlaceDetails {
+	if !opts.E164 {
+		return results
+	}
+
+	formatted := make([]places.PlaceDetails, len(results))
+	for i, place := range results {
+		normalized := place.PhoneE164
+		if normalized == "" {
+			normalized = phone.E164(place.FormattedPhoneNumber)
+		}
+		if normalized != "" {
+			place.FormattedPhoneNumber = normalized
+		}
+		formatted[i] = place
+	}
+	return formatted
+}
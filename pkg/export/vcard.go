@@ -0,0 +1,51 @@
+package export
+
+import (
+	"fmt"
+	"medina-consultancy-api/pkg/places"
+	"strings"
+)
+
+var vcardEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\r\n", `\n`, "\n", `\n`)
+
+// VCard writes one vCard 3.0 contact per place so results can be imported in phones.
+func VCard(results []places.PlaceDetails) ([]byte, error) {
+	var buf strings.Builder
+
+	for _, place := range results {
+		name := vcardEscaper.Replace(place.Name)
+
+		lines := []string{
+			"BEGIN:VCARD",
+			"VERSION:3.0",
+			"FN:" + name,
+			"N:;" + name + ";;;",
+			"ORG:" + name,
+		}
+
+		if place.FormattedPhoneNumber != "" {
+			lines = append(lines, "TEL;TYPE=WORK,VOICE:"+vcardEscaper.Replace(place.FormattedPhoneNumber))
+		}
+		for _, number := range place.WebsitePhones {
+			lines = append(lines, "TEL;TYPE=WORK:"+vcardEscaper.Replace(number))
+		}
+		for _, email := range place.Emails {
+			lines = append(lines, "EMAIL;TYPE=WORK:"+vcardEscaper.Replace(email))
+		}
+		if place.Website != "" {
+			lines = append(lines, "URL:"+vcardEscaper.Replace(place.Website))
+		}
+		if place.FormattedAddress != "" {
+			lines = append(lines, fmt.Sprintf("ADR;TYPE=WORK:;;%s;;;;", vcardEscaper.Replace(place.FormattedAddress)))
+		}
+		if place.URL != "" {
+			lines = append(lines, "NOTE:"+vcardEscaper.Replace(place.URL))
+		}
+
+		lines = append(lines, "END:VCARD")
+		buf.WriteString(strings.Join(lines, "\r\n"))
+		buf.WriteString("\r\n")
+	}
+
+	return []byte(buf.String()), nil
+}
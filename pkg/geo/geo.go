@@ -0,0 +1,171 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+const earthRadiusMeters = 6371000.0
+
+type LatLng struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+type BoundingBox struct {
+	South float64 `json:"south"`
+	West  float64 `json:"west"`
+	North float64 `json:"north"`
+	East  float64 `json:"east"`
+}
+
+// Polygon is a list of linear rings: the first is the outer boundary, the rest are holes.
+type Polygon [][]LatLng
+
+// Distance returns the great-circle distance between two points in meters.
+func Distance(a LatLng, b LatLng) float64 {
+	lat1 := a.Lat * math.Pi / 180
+	lat2 := b.Lat * math.Pi / 180
+	dLat := (b.Lat - a.Lat) * math.Pi / 180
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(h))
+}
+
+// CircleBounds returns the bounding box of a circle around center.
+func CircleBounds(center LatLng, radiusMeters float64) BoundingBox {
+	dLat := radiusMeters / earthRadiusMeters * 180 / math.Pi
+	dLng := dLat / math.Max(math.Cos(center.Lat*math.Pi/180), 0.01)
+
+	return BoundingBox{
+		South: center.Lat - dLat,
+		West:  center.Lng - dLng,
+		North: center.Lat + dLat,
+		East:  center.Lng + dLng,
+	}
+}
+
+func (b BoundingBox) Valid() bool {
+	return b.South < b.North && b.West < b.East &&
+		b.South >= -90 && b.North <= 90 && b.West >= -180 && b.East <= 180
+}
+
+func (b BoundingBox) Contains(point LatLng) bool {
+	return point.Lat >= b.South && point.Lat <= b.North && point.Lng >= b.West && point.Lng <= b.East
+}
+
+// Size returns the width of the box along its middle latitude and its height, in meters.
+func (b BoundingBox) Size() (widthMeters float64, heightMeters float64) {
+	midLat := (b.South + b.North) / 2
+	widthMeters = Distance(LatLng{midLat, b.West}, LatLng{midLat, b.East})
+	heightMeters = Distance(LatLng{b.South, b.West}, LatLng{b.North, b.West})
+	return widthMeters, heightMeters
+}
+
+// Grid splits the box into square cells of roughly cellMeters per side and returns
+// their centers, row by row from the south-west corner.
+func (b BoundingBox) Grid(cellMeters float64) []LatLng {
+	widthMeters, heightMeters := b.Size()
+
+	rows := int(math.Max(1, math.Ceil(heightMeters/cellMeters)))
+	cols := int(math.Max(1, math.Ceil(widthMeters/cellMeters)))
+
+	latStep := (b.North - b.South) / float64(rows)
+	lngStep := (b.East - b.West) / float64(cols)
+
+	centers := make([]LatLng, 0, rows*cols)
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			centers = append(centers, LatLng{
+				Lat: b.South + latStep*(float64(row)+0.5),
+				Lng: b.West + lngStep*(float64(col)+0.5),
+			})
+		}
+	}
+
+	return centers
+}
+
+// ParseGeoJSONPolygon accepts a GeoJSON Polygon geometry or a Feature wrapping one.
+// GeoJSON positions are [lng, lat].
+func ParseGeoJSONPolygon(data []byte) (Polygon, error) {
+	var object struct {
+		Type        string          `json:"type"`
+		Coordinates [][][]float64   `json:"coordinates"`
+		Geometry    json.RawMessage `json:"geometry"`
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %w", err)
+	}
+
+	if object.Type == "Feature" {
+		return ParseGeoJSONPolygon(object.Geometry)
+	}
+
+	if object.Type != "Polygon" {
+		return nil, fmt.Errorf("unsupported GeoJSON type %q, expected Polygon", object.Type)
+	}
+
+	if len(object.Coordinates) == 0 {
+		return nil, fmt.Errorf("polygon has no rings")
+	}
+
+	polygon := make(Polygon, 0, len(object.Coordinates))
+	for _, ring := range object.Coordinates {
+		if len(ring) < 4 {
+			return nil, fmt.Errorf("polygon rings need at least 4 positions")
+		}
+
+		points := make([]LatLng, 0, len(ring))
+		for _, position := range ring {
+			if len(position) < 2 {
+				return nil, fmt.Errorf("invalid position in polygon")
+			}
+			points = append(points, LatLng{Lat: position[1], Lng: position[0]})
+		}
+		polygon = append(polygon, points)
+	}
+
+	return polygon, nil
+}
+
+func (p Polygon) Bounds() BoundingBox {
+	box := BoundingBox{South: 90, West: 180, North: -90, East: -180}
+	for _, point := range p[0] {
+		box.South = math.Min(box.South, point.Lat)
+		box.North = math.Max(box.North, point.Lat)
+		box.West = math.Min(box.West, point.Lng)
+		box.East = math.Max(box.East, point.Lng)
+	}
+	return box
+}
+
+// Contains reports whether the point is inside the outer ring and outside every hole.
+func (p Polygon) Contains(point LatLng) bool {
+	if len(p) == 0 || !ringContains(p[0], point) {
+		return false
+	}
+
+	for _, hole := range p[1:] {
+		if ringContains(hole, point) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ringContains is the even-odd ray casting test.
+func ringContains(ring []LatLng, point LatLng) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Lat > point.Lat) != (b.Lat > point.Lat) &&
+			point.Lng < (b.Lng-a.Lng)*(point.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lng {
+			inside = !inside
+		}
+	}
+	return inside
+}
@@ -0,0 +1,84 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPolygonContains(t *testing.T) {
+	// a 4x4 square with a 2x2 hole in the middle, GeoJSON order [lng, lat]
+	withHole := `{"type":"Polygon","coordinates":[
+		[[0,0],[4,0],[4,4],[0,4],[0,0]],
+		[[1,1],[3,1],[3,3],[1,3],[1,1]]
+	]}`
+	// an L shape whose bounding box covers the missing corner
+	lShape := `{"type":"Feature","geometry":{"type":"Polygon","coordinates":[
+		[[0,0],[4,0],[4,2],[2,2],[2,4],[0,4],[0,0]]
+	]}}`
+
+	tests := []struct {
+		name    string
+		geojson string
+		point   LatLng
+		want    bool
+	}{
+		{"inside the ring", withHole, LatLng{Lat: 0.5, Lng: 0.5}, true},
+		{"inside the hole", withHole, LatLng{Lat: 2, Lng: 2}, false},
+		{"between the hole and the ring", withHole, LatLng{Lat: 2, Lng: 3.5}, true},
+		{"outside", withHole, LatLng{Lat: 5, Lng: 2}, false},
+		{"inside the L", lShape, LatLng{Lat: 3, Lng: 1}, true},
+		{"missing corner of the L", lShape, LatLng{Lat: 3, Lng: 3}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			polygon, err := ParseGeoJSONPolygon([]byte(tt.geojson))
+			if err != nil {
+				t.Fatalf("ParseGeoJSONPolygon: %v", err)
+			}
+			if got := polygon.Contains(tt.point); got != tt.want {
+				t.Errorf("Contains(%+v) = %v, want %v", tt.point, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGeoJSONPolygonErrors(t *testing.T) {
+	tests := []string{
+		`not json`,
+		`{"type":"Point","coordinates":[0,0]}`,
+		`{"type":"Polygon","coordinates":[]}`,
+		`{"type":"Polygon","coordinates":[[[0,0],[1,0],[0,0]]]}`,
+		`{"type":"Polygon","coordinates":[[[0,0],[1],[1,1],[0,0]]]}`,
+	}
+
+	for _, geojson := range tests {
+		if _, err := ParseGeoJSONPolygon([]byte(geojson)); err == nil {
+			t.Errorf("ParseGeoJSONPolygon(%s) succeeded, want an error", geojson)
+		}
+	}
+}
+
+func TestBoundingBoxGrid(t *testing.T) {
+	// about 10km by 10km near São Paulo
+	box := BoundingBox{South: -23.6, West: -46.7, North: -23.51, East: -46.602}
+
+	width, height := box.Size()
+	if math.Abs(width-10000) > 100 || math.Abs(height-10000) > 100 {
+		t.Fatalf("Size = %.0f x %.0f, want about 10000 x 10000", width, height)
+	}
+
+	centers := box.Grid(6000)
+	if len(centers) != 4 {
+		t.Fatalf("Grid(6000) = %d cells, want 4", len(centers))
+	}
+	for _, center := range centers {
+		if !box.Contains(center) {
+			t.Errorf("center %+v is outside the box", center)
+		}
+	}
+
+	if got := len(box.Grid(20000)); got != 1 {
+		t.Errorf("Grid(20000) = %d cells, want 1", got)
+	}
+}
@@ -68,23 +68,42 @@ func CreatePixPayment(ctx context.Context, req PixPaymentRequest) (*PixPaymentRe
 	return response, nil
 }
 
-func GetPaymentStatus(ctx context.Context, paymentID int) (string, error) {
+type PaymentInfo struct {
+	ID                string
+	Status            string
+	ExternalReference string
+}
+
+func GetPayment(ctx context.Context, paymentID int) (*PaymentInfo, error) {
 	accessToken := os.Getenv("MERCADO_PAGO_ACCESS_TOKEN")
 	if accessToken == "" {
-		return "", fmt.Errorf("MERCADO_PAGO_ACCESS_TOKEN is not set")
+		return nil, fmt.Errorf("MERCADO_PAGO_ACCESS_TOKEN is not set")
 	}
 
 	cfg, err := config.New(accessToken)
 	if err != nil {
-		return "", fmt.Errorf("failed to create mercado pago config: %w", err)
+		return nil, fmt.Errorf("failed to create mercado pago config: %w", err)
 	}
 
 	client := payment.NewClient(cfg)
 
 	resource, err := client.Get(ctx, paymentID)
 	if err != nil {
-		return "", fmt.Errorf("failed to get payment: %w", err)
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+
+	return &PaymentInfo{
+		ID:                fmt.Sprintf("%d", resource.ID),
+		Status:            resource.Status,
+		ExternalReference: resource.ExternalReference,
+	}, nil
+}
+
+func GetPaymentStatus(ctx context.Context, paymentID int) (string, error) {
+	info, err := GetPayment(ctx, paymentID)
+	if err != nil {
+		return "", err
 	}
 
-	return resource.Status, nil
+	return info.Status, nil
 }
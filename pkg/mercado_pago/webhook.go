@@ -0,0 +1,57 @@
+package mercadopago
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ValidateWebhookSignature checks the x-signature header of a notification
+// ("ts=<timestamp>,v1=<hmac>") against MERCADO_PAGO_WEBHOOK_SECRET.
+func ValidateWebhookSignature(xSignature string, xRequestID string, dataID string) error {
+	secret := os.Getenv("MERCADO_PAGO_WEBHOOK_SECRET")
+	if secret == "" {
+		return fmt.Errorf("MERCADO_PAGO_WEBHOOK_SECRET is not set")
+	}
+
+	var ts, signature string
+	for _, part := range strings.Split(xSignature, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			continue
+		}
+		switch key {
+		case "ts":
+			ts = value
+		case "v1":
+			signature = value
+		}
+	}
+
+	if ts == "" || signature == "" {
+		return fmt.Errorf("malformed x-signature header")
+	}
+
+	// only the parts present in the notification are part of the signed manifest
+	var manifest strings.Builder
+	if dataID != "" {
+		manifest.WriteString(fmt.Sprintf("id:%s;", strings.ToLower(dataID)))
+	}
+	if xRequestID != "" {
+		manifest.WriteString(fmt.Sprintf("request-id:%s;", xRequestID))
+	}
+	manifest.WriteString(fmt.Sprintf("ts:%s;", ts))
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(manifest.String()))
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("invalid webhook signature")
+	}
+
+	return nil
+}
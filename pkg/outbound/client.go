@@ -0,0 +1,189 @@
+package outbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxBackoff = 30 * time.Second
+
+type Config struct {
+	RequestsPerSecond float64
+	Burst             int
+	MaxConcurrent     int           // requests in flight at the same time
+	Timeout           time.Duration // per attempt
+	MaxRetries        int
+	Backoff           time.Duration // first retry delay, doubled on every attempt
+	MaxBodyBytes      int64         // longer bodies are truncated, 0 reads them whole
+
+	Transport http.RoundTripper // nil uses http.DefaultTransport
+}
+
+// RetryFunc tells whether a response should be retried. APIs such as Google Places report
+// quota errors with a 200 status, so the body is given too.
+type RetryFunc func(status int, body []byte) bool
+
+// Client is an HTTP client shared by every search of the process so that the rate limit
+// and the concurrency bound apply to all of them together.
+type Client struct {
+	config  Config
+	http    *http.Client
+	limiter *TokenBucket
+	slots   chan struct{}
+}
+
+func NewClient(config Config) *Client {
+	return &Client{
+		config:  config,
+		http:    &http.Client{Timeout: config.Timeout, Transport: config.Transport},
+		limiter: NewTokenBucket(config.RequestsPerSecond, config.Burst),
+		slots:   make(chan struct{}, config.MaxConcurrent),
+	}
+}
+
+// ConfigFromEnv reads <PREFIX>_QPS, <PREFIX>_BURST, <PREFIX>_MAX_CONCURRENCY,
+// <PREFIX>_TIMEOUT, <PREFIX>_MAX_RETRIES and <PREFIX>_BACKOFF over the given defaults.
+func ConfigFromEnv(prefix string, defaults Config) Config {
+	config := defaults
+
+	if value, err := strconv.ParseFloat(os.Getenv(prefix+"_QPS"), 64); err == nil && value > 0 {
+		config.RequestsPerSecond = value
+	}
+	if value, err := strconv.Atoi(os.Getenv(prefix + "_BURST")); err == nil && value > 0 {
+		config.Burst = value
+	}
+	if value, err := strconv.Atoi(os.Getenv(prefix + "_MAX_CONCURRENCY")); err == nil && value > 0 {
+		config.MaxConcurrent = value
+	}
+	if value, err := time.ParseDuration(os.Getenv(prefix + "_TIMEOUT")); err == nil && value > 0 {
+		config.Timeout = value
+	}
+	if value, err := strconv.Atoi(os.Getenv(prefix + "_MAX_RETRIES")); err == nil && value >= 0 {
+		config.MaxRetries = value
+	}
+	if value, err := time.ParseDuration(os.Getenv(prefix + "_BACKOFF")); err == nil && value > 0 {
+		config.Backoff = value
+	}
+
+	return config
+}
+
+// RetryServerErrors retries 429 and 5xx responses.
+func RetryServerErrors(status int, _ []byte) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// Get fetches the URL and returns the status and body of the last attempt. Network
+// errors and responses accepted by retry are retried with exponential backoff; when the
+// retries run out the last response is returned as is for the caller to report.
+func (c *Client) Get(ctx context.Context, rawURL string, header http.Header, retry RetryFunc) (int, []byte, error) {
+	var lastErr error
+	var lastStatus int
+	var lastBody []byte
+
+	for attempt := 0; attempt <= c.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if err := sleep(ctx, c.backoff(attempt)); err != nil {
+				return 0, nil, err
+			}
+		}
+
+		status, body, err := c.do(ctx, rawURL, header)
+		if ctx.Err() != nil {
+			return 0, nil, ctx.Err()
+		}
+
+		switch {
+		case err != nil:
+			lastErr = err
+		case retry != nil && retry(status, body):
+			lastErr = fmt.Errorf("retryable response with status %d", status)
+			lastStatus, lastBody = status, body
+		default:
+			return status, body, nil
+		}
+
+		log.Printf("Outbound request failed (attempt %d/%d): %v", attempt+1, c.config.MaxRetries+1, lastErr)
+	}
+
+	if lastBody != nil {
+		return lastStatus, lastBody, nil
+	}
+	return 0, nil, fmt.Errorf("giving up after %d attempts: %w", c.config.MaxRetries+1, lastErr)
+}
+
+func (c *Client) do(ctx context.Context, rawURL string, header http.Header) (int, []byte, error) {
+	select {
+	case c.slots <- struct{}{}:
+		defer func() { <-c.slots }()
+	case <-ctx.Done():
+		return 0, nil, ctx.Err()
+	}
+
+	if err := c.limiter.Wait(ctx); err != nil {
+		return 0, nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create request: %w", redactURL(err))
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to fetch data: %w", redactURL(err))
+	}
+	defer resp.Body.Close()
+
+	var reader io.Reader = resp.Body
+	if c.config.MaxBodyBytes > 0 {
+		reader = io.LimitReader(resp.Body, c.config.MaxBodyBytes)
+	}
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return resp.StatusCode, body, nil
+}
+
+// redactURL drops the query string from the URL of a *url.Error, it carries API keys
+// such as the Google Places key. The error still unwraps to the cause.
+func redactURL(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+	base, _, _ := strings.Cut(urlErr.URL, "?")
+	return &url.Error{Op: urlErr.Op, URL: base, Err: urlErr.Err}
+}
+
+// backoff doubles the delay on every attempt, with up to 50% jitter so that concurrent
+// searches do not retry in lockstep.
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := min(c.config.Backoff<<(attempt-1), maxBackoff)
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
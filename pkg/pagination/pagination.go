@@ -0,0 +1,230 @@
+package pagination
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+
+	// MaxPage keeps the offset of page pagination small, deeper lists use the cursor
+	MaxPage = 10000
+)
+
+// ErrInvalidSort is returned by Find when the sort is not allowed for the list.
+var ErrInvalidSort = errors.New("invalid sort")
+
+// Params are the paging, sorting and filtering options of a list request.
+type Params struct {
+	Page    int
+	Limit   int
+	Cursor  uint   // ID of the last item already seen, switches to keyset pagination
+	Sort    string // defaults to created_at
+	Desc    bool   // defaults to true
+	Filters Filters
+}
+
+type Filters struct {
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	City          string
+	Query         string // substring, case insensitive
+	MinResults    int
+	Status        string
+}
+
+// Columns describes how the filters and sorts apply to a table. A filter whose column is
+// empty is not supported by that list and is ignored.
+type Columns struct {
+	City     string
+	Query    string
+	Results  string
+	Status   string
+	Sortable []string // allowed in addition to created_at and id
+}
+
+type Meta struct {
+	Page       int    `json:"page,omitempty"`
+	Limit      int    `json:"limit"`
+	Total      int64  `json:"total"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Parse reads page, limit, cursor, sort, order, created_after, created_before, city, query,
+// min_results and status from the query string.
+func Parse(c *gin.Context) (Params, error) {
+	params := Params{Page: 1, Limit: DefaultLimit, Sort: "created_at", Desc: true}
+
+	if value := c.Query("page"); value != "" {
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 1 {
+			return params, fmt.Errorf("page must be a positive number")
+		}
+		if page > MaxPage {
+			return params, fmt.Errorf("page must be at most %d, use the cursor for deeper pages", MaxPage)
+		}
+		params.Page = page
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return params, fmt.Errorf("limit must be a positive number")
+		}
+		params.Limit = min(limit, MaxLimit)
+	}
+
+	if value := c.Query("cursor"); value != "" {
+		cursor, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return params, fmt.Errorf("invalid cursor")
+		}
+		params.Cursor = uint(cursor)
+	}
+
+	if value := c.Query("sort"); value != "" {
+		params.Sort = value
+	}
+
+	switch c.Query("order") {
+	case "", "desc":
+	case "asc":
+		params.Desc = false
+	default:
+		return params, fmt.Errorf("order must be asc or desc")
+	}
+
+	var err error
+	if params.Filters.CreatedAfter, err = parseDate(c.Query("created_after")); err != nil {
+		return params, fmt.Errorf("invalid created_after: %w", err)
+	}
+	if params.Filters.CreatedBefore, err = parseDate(c.Query("created_before")); err != nil {
+		return params, fmt.Errorf("invalid created_before: %w", err)
+	}
+
+	if value := c.Query("min_results"); value != "" {
+		if params.Filters.MinResults, err = strconv.Atoi(value); err != nil {
+			return params, fmt.Errorf("min_results must be a number")
+		}
+	}
+
+	params.Filters.City = c.Query("city")
+	params.Filters.Query = c.Query("query")
+	params.Filters.Status = c.Query("status")
+
+	return params, nil
+}
+
+// parseDate accepts RFC 3339 timestamps or plain dates.
+func parseDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return &parsed, nil
+		}
+	}
+
+	return nil, fmt.Errorf("use YYYY-MM-DD or RFC 3339")
+}
+
+// likeEscaper escapes the LIKE wildcards, backslash is the default escape character.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// Contains is the ILIKE pattern matching value anywhere, with its wildcards taken literally.
+func Contains(value string) string {
+	return "%" + likeEscaper.Replace(value) + "%"
+}
+
+// cursorSort tells whether the sort keeps the order of IDs, which cursors rely on.
+func cursorSort(sort string) bool {
+	return sort == "created_at" || sort == "id"
+}
+
+// Find applies the filters, sort and page to the query and loads one page of T. With a
+// cursor the page number is ignored and the results continue after the cursor ID, which
+// requires sorting by created_at or id.
+func Find[T any](query *gorm.DB, params Params, columns Columns) ([]T, *Meta, error) {
+	if !cursorSort(params.Sort) && !slices.Contains(columns.Sortable, params.Sort) {
+		return nil, nil, fmt.Errorf("%w: cannot sort by %q", ErrInvalidSort, params.Sort)
+	}
+	if params.Cursor > 0 && !cursorSort(params.Sort) {
+		return nil, nil, fmt.Errorf("%w: cursor pagination requires sorting by created_at or id", ErrInvalidSort)
+	}
+
+	query = applyFilters(query, params.Filters, columns)
+
+	meta := &Meta{Limit: params.Limit}
+	if err := query.Session(&gorm.Session{}).Count(&meta.Total).Error; err != nil {
+		return nil, nil, err
+	}
+
+	direction := "ASC"
+	if params.Desc {
+		direction = "DESC"
+	}
+
+	if params.Cursor > 0 {
+		if params.Desc {
+			query = query.Where("id < ?", params.Cursor)
+		} else {
+			query = query.Where("id > ?", params.Cursor)
+		}
+		// IDs grow with created_at, ordering by id keeps the cursor stable
+		query = query.Order("id " + direction)
+	} else {
+		meta.Page = params.Page
+		query = query.Order(params.Sort + " " + direction).Order("id " + direction).Offset((params.Page - 1) * params.Limit)
+	}
+
+	// one extra row tells whether there is a next page
+	var items []T
+	if err := query.Limit(params.Limit + 1).Find(&items).Error; err != nil {
+		return nil, nil, err
+	}
+
+	if len(items) > params.Limit {
+		items = items[:params.Limit]
+		meta.HasMore = true
+	}
+	if meta.HasMore && cursorSort(params.Sort) {
+		meta.NextCursor = strconv.FormatUint(reflect.ValueOf(items[len(items)-1]).FieldByName("ID").Uint(), 10)
+	}
+
+	return items, meta, nil
+}
+
+func applyFilters(query *gorm.DB, filters Filters, columns Columns) *gorm.DB {
+	if filters.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *filters.CreatedAfter)
+	}
+	if filters.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *filters.CreatedBefore)
+	}
+	if filters.City != "" && columns.City != "" {
+		query = query.Where("LOWER("+columns.City+") = LOWER(?)", filters.City)
+	}
+	if filters.Query != "" && columns.Query != "" {
+		query = query.Where(columns.Query+" ILIKE ?", Contains(filters.Query))
+	}
+	if filters.MinResults > 0 && columns.Results != "" {
+		query = query.Where(columns.Results+" >= ?", filters.MinResults)
+	}
+	if filters.Status != "" && columns.Status != "" {
+		query = query.Where(columns.Status+" = ?", filters.Status)
+	}
+	return query
+}
@@ -0,0 +1,84 @@
+package pagination
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func parseQuery(t *testing.T, query string) (Params, error) {
+	t.Helper()
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/?"+query, nil)
+	return Parse(c)
+}
+
+func TestParse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		query   string
+		check   func(Params) bool
+		wantErr bool
+	}{
+		{"defaults", "", func(p Params) bool {
+			return p.Page == 1 && p.Limit == DefaultLimit && p.Sort == "created_at" && p.Desc && p.Cursor == 0
+		}, false},
+		{"page and limit", "page=3&limit=50", func(p Params) bool { return p.Page == 3 && p.Limit == 50 }, false},
+		{"limit capped", "limit=1000", func(p Params) bool { return p.Limit == MaxLimit }, false},
+		{"last page allowed", "page=10000", func(p Params) bool { return p.Page == MaxPage }, false},
+		{"page too deep", "page=10001", nil, true},
+		{"page overflowing the offset", "page=9223372036854775807&limit=100", nil, true},
+		{"page zero", "page=0", nil, true},
+		{"page not a number", "page=abc", nil, true},
+		{"negative limit", "limit=-5", nil, true},
+		{"cursor", "cursor=42", func(p Params) bool { return p.Cursor == 42 }, false},
+		{"negative cursor", "cursor=-1", nil, true},
+		{"ascending", "sort=results&order=asc", func(p Params) bool { return p.Sort == "results" && !p.Desc }, false},
+		{"invalid order", "order=up", nil, true},
+		{"dates", "created_after=2026-03-01&created_before=2026-04-01T00:00:00Z", func(p Params) bool {
+			return p.Filters.CreatedAfter.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) &&
+				p.Filters.CreatedBefore.Equal(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC))
+		}, false},
+		{"invalid date", "created_after=01/03/2026", nil, true},
+		{"filters", "city=Campinas&query=pizza&min_results=10&status=done", func(p Params) bool {
+			return p.Filters.City == "Campinas" && p.Filters.Query == "pizza" && p.Filters.MinResults == 10 && p.Filters.Status == "done"
+		}, false},
+		{"invalid min_results", "min_results=many", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := parseQuery(t, tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.check != nil && !tt.check(params) {
+				t.Errorf("Parse(%q) = %+v", tt.query, params)
+			}
+		})
+	}
+}
+
+func TestContains(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"pizza", "%pizza%"},
+		{"100%", `%100\%%`},
+		{"a_b", `%a\_b%`},
+		{`c:\temp`, `%c:\\temp%`},
+		{`\%_`, `%\\\%\_%`},
+		{"", "%%"},
+	}
+
+	for _, tt := range tests {
+		if got := Contains(tt.value); got != tt.want {
+			t.Errorf("Contains(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
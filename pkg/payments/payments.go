@@ -0,0 +1,61 @@
+package payments
+
+import (
+	"fmt"
+	"log"
+	"medina-consultancy-api/database"
+	"medina-consultancy-api/models"
+	"medina-consultancy-api/pkg/credits"
+	"time"
+)
+
+// ApplyOrderStatus stores the latest Mercado Pago status of an order and credits the
+// user when it is approved. It reports whether credits were added by this call.
+func ApplyOrderStatus(order *models.Order, status string) (bool, error) {
+	if status != "" && status != order.Status {
+		if err := database.DB.Model(order).Update("status", status).Error; err != nil {
+			return false, fmt.Errorf("failed to update order status: %w", err)
+		}
+		order.Status = status
+	}
+
+	if order.Status != "approved" || order.CreditsAdded {
+		return false, nil
+	}
+
+	credited, err := credits.CreditOrder(order)
+	if err != nil {
+		return false, fmt.Errorf("failed to credit order %d: %w", order.ID, err)
+	}
+
+	if credited {
+		log.Printf("Credited %d credit(s) to user %d for order %d", order.CreditPackage.Credits, order.UserID, order.ID)
+	}
+
+	return credited, nil
+}
+
+// ApplyInvoiceStatus maps the status of a settled card charge onto its invoice.
+func ApplyInvoiceStatus(invoice *models.Invoice, paymentID string, status string) error {
+	if invoice.Status == "paid" {
+		return nil
+	}
+
+	switch status {
+	case "approved":
+		now := time.Now()
+		invoice.Status = "paid"
+		invoice.PaidAt = &now
+	case "pending", "in_process", "authorized":
+		invoice.Status = "processing"
+	default:
+		invoice.Status = "failed"
+	}
+	invoice.MercadoPagoID = paymentID
+
+	if err := database.DB.Save(invoice).Error; err != nil {
+		return fmt.Errorf("failed to update invoice %d: %w", invoice.ID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package phone
+
+import "strings"
+
+const (
+	TypeMobile   = "mobile"
+	TypeLandline = "landline"
+	TypeUnknown  = "unknown"
+)
+
+// Info is a phone number normalized and classified.
+type Info struct {
+	E164     string `json:"e164"`
+	Type     string `json:"type"`     // mobile, landline or unknown
+	WhatsApp bool   `json:"whatsapp"` // likely reachable on WhatsApp
+}
+
+// Classify normalizes the number for the country and tells Brazilian mobiles from
+// landlines: after the area code, mobiles have 9 digits starting with 9 and landlines
+// have 8 digits starting with 2 to 5. Other countries are left unknown.
+func Classify(raw string, country string) Info {
+	info := Info{E164: E164For(raw, country), Type: TypeUnknown}
+	if !strings.HasPrefix(info.E164, "+"+brazilCountryCode) {
+		return info
+	}
+
+	subscriber := info.E164[len(brazilCountryCode)+3:]
+	switch {
+	case len(subscriber) == 9 && subscriber[0] == '9':
+		info.Type = TypeMobile
+	case len(subscriber) == 8 && subscriber[0] >= '2' && subscriber[0] <= '5':
+		info.Type = TypeLandline
+	}
+
+	// almost every Brazilian mobile has WhatsApp, landlines only when registered with
+	// WhatsApp Business which cannot be told from the number
+	info.WhatsApp = info.Type == TypeMobile
+
+	return info
+}
+
+// WhatsAppLink is the wa.me link of a number normalized to E.164.
+func WhatsAppLink(e164 string) string {
+	if e164 == "" {
+		return ""
+	}
+	return "https://wa.me/" + strings.TrimPrefix(e164, "+")
+}
@@ -0,0 +1,133 @@
+package phone
+
+import (
+	"strings"
+)
+
+const (
+	brazilCountryCode = "55"
+
+	// DefaultCountry is assumed for numbers without a country code when the search does
+	// not say otherwise.
+	DefaultCountry = "BR"
+)
+
+// callingCodes of the countries searched, by ISO 3166-1 alpha-2 code.
+var callingCodes = map[string]string{
+	"BR": brazilCountryCode,
+	"AR": "54",
+	"CL": "56",
+	"CO": "57",
+	"ES": "34",
+	"MX": "52",
+	"PE": "51",
+	"PT": "351",
+	"PY": "595",
+	"US": "1",
+	"UY": "598",
+}
+
+// KnownCountry tells whether numbers of the country can be normalized.
+func KnownCountry(country string) bool {
+	_, ok := callingCodes[strings.ToUpper(country)]
+	return ok
+}
+
+// E164 normalizes a phone number to E.164 (+5511912345678). Numbers without a country
+// code are assumed to be Brazilian. Returns "" when the number cannot be normalized.
+func E164(raw string) string {
+	return E164For(raw, DefaultCountry)
+}
+
+// E164For normalizes a phone number, taking numbers without a country code as numbers
+// of the given country.
+func E164For(raw string, country string) string {
+	raw = strings.TrimSpace(raw)
+	digits := digitsOnly(raw)
+
+	switch {
+	case strings.HasPrefix(raw, "+"):
+		// already international
+	case strings.HasPrefix(digits, "00"):
+		digits = digits[2:]
+	case strings.ToUpper(country) == DefaultCountry || !KnownCountry(country):
+		digits = nationalToInternational(digits)
+	default:
+		digits = foreignToInternational(digits, strings.ToUpper(country))
+	}
+
+	if len(digits) < 8 || len(digits) > 15 {
+		return ""
+	}
+
+	if strings.HasPrefix(digits, brazilCountryCode) {
+		digits = addNinthDigit(digits)
+	}
+
+	return "+" + digits
+}
+
+// nationalToInternational handles Brazilian dialing formats: (11) 91234-5678,
+// 011 91234-5678 with the trunk prefix and 0 21 11 91234-5678 with a carrier code.
+func nationalToInternational(digits string) string {
+	// 0800, 0300 and similar non-geographic numbers cannot be dialed from abroad
+	if len(digits) == 11 && strings.HasPrefix(digits, "0") && digits[1] != '0' && digits[3] == '0' {
+		return ""
+	}
+
+	if strings.HasPrefix(digits, "0") {
+		digits = digits[1:]
+		if len(digits) == 12 || len(digits) == 13 {
+			digits = digits[2:] // carrier selection code
+		}
+	}
+
+	switch len(digits) {
+	case 10, 11:
+		return brazilCountryCode + digits
+	case 12, 13:
+		if strings.HasPrefix(digits, brazilCountryCode) {
+			return digits
+		}
+	}
+
+	return ""
+}
+
+// foreignToInternational drops the trunk prefix (0, or 1 in North America) and adds the
+// calling code of the country.
+func foreignToInternational(digits string, country string) string {
+	code := callingCodes[country]
+
+	if code == "1" {
+		if len(digits) == 11 && strings.HasPrefix(digits, "1") {
+			return digits
+		}
+		if len(digits) == 10 {
+			return code + digits
+		}
+		return ""
+	}
+
+	return code + strings.TrimPrefix(digits, "0")
+}
+
+// addNinthDigit completes Brazilian mobile numbers written in the old 8-digit format,
+// every mobile number gained a leading 9 by 2016.
+func addNinthDigit(digits string) string {
+	subscriber := digits[len(brazilCountryCode)+2:]
+	if len(subscriber) == 8 && subscriber[0] >= '6' {
+		return digits[:len(brazilCountryCode)+2] + "9" + subscriber
+	}
+	return digits
+}
+
+func digitsOnly(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
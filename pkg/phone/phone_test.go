@@ -0,0 +1,85 @@
+package phone
+
+import "testing"
+
+func TestE164(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"mobile with area code", "(11) 91234-5678", "+5511912345678"},
+		{"landline with area code", "(11) 3456-7890", "+551134567890"},
+		{"mobile with country code", "+55 11 91234-5678", "+5511912345678"},
+		{"landline with country code", "+55 (21) 2345-6789", "+552123456789"},
+		{"country code without plus", "55 11 91234 5678", "+5511912345678"},
+		{"international prefix", "00 55 11 91234-5678", "+5511912345678"},
+		{"trunk zero on mobile", "011 91234-5678", "+5511912345678"},
+		{"trunk zero on landline", "011 3456-7890", "+551134567890"},
+		{"trunk zero and carrier code", "0 21 11 91234-5678", "+5511912345678"},
+		{"old 8 digit mobile", "(11) 8123-4567", "+5511981234567"},
+		{"old 8 digit mobile with country code", "+55 11 8123-4567", "+5511981234567"},
+		{"dotted", "11.91234.5678", "+5511912345678"},
+		{"other country with plus", "+351 912 345 678", "+351912345678"},
+		{"toll free", "0800 123 4567", ""},
+		{"too short", "3456-7890", ""},
+		{"too long", "+55 11 91234-5678 1234 5678", ""},
+		{"letters only", "ligue já", ""},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := E164(tt.raw); got != tt.want {
+				t.Errorf("E164(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestE164For(t *testing.T) {
+	tests := []struct {
+		raw     string
+		country string
+		want    string
+	}{
+		{"(11) 91234-5678", "BR", "+5511912345678"},
+		{"(11) 91234-5678", "", "+5511912345678"},
+		{"(11) 91234-5678", "ZZ", "+5511912345678"},
+		{"912 345 678", "PT", "+351912345678"},
+		{"011 4321-1234", "AR", "+541143211234"},
+		{"(415) 555-2671", "US", "+14155552671"},
+		{"1 415 555 2671", "us", "+14155552671"},
+		{"555-2671", "US", ""},
+		{"+55 11 91234-5678", "US", "+5511912345678"},
+	}
+
+	for _, tt := range tests {
+		if got := E164For(tt.raw, tt.country); got != tt.want {
+			t.Errorf("E164For(%q, %q) = %q, want %q", tt.raw, tt.country, got, tt.want)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		raw      string
+		country  string
+		want     string
+		whatsApp bool
+	}{
+		{"(11) 91234-5678", "BR", TypeMobile, true},
+		{"(11) 8123-4567", "BR", TypeMobile, true},
+		{"(11) 3456-7890", "BR", TypeLandline, false},
+		{"+55 11 1234-5678", "BR", TypeUnknown, false},
+		{"912 345 678", "PT", TypeUnknown, false},
+		{"invalid", "BR", TypeUnknown, false},
+	}
+
+	for _, tt := range tests {
+		info := Classify(tt.raw, tt.country)
+		if info.Type != tt.want || info.WhatsApp != tt.whatsApp {
+			t.Errorf("Classify(%q, %q) = %s/%v, want %s/%v", tt.raw, tt.country, info.Type, info.WhatsApp, tt.want, tt.whatsApp)
+		}
+	}
+}
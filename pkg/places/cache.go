@@ -0,0 +1,177 @@
+package places
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"medina-consultancy-api/database"
+	"medina-consultancy-api/models"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+const defaultMemoryCacheSize = 10000
+
+// Cache stores provider responses for a limited time. Get reports false for missing and
+// expired keys.
+type Cache interface {
+	Get(ctx context.Context, key string) (string, bool)
+	Set(ctx context.Context, key string, value string, ttl time.Duration)
+}
+
+// CacheTTL is how long each kind of cached data stays fresh.
+type CacheTTL struct {
+	Phone   time.Duration
+	Website time.Duration
+	Hours   time.Duration // opening hours, short as they carry open now
+	Search  time.Duration // text and nearby search pages
+}
+
+var (
+	memoryCacheOnce sync.Once
+	memoryCache     *MemoryCache
+)
+
+// NewCacheFromEnv selects the cache from PLACES_CACHE: postgres, memory (default) or off,
+// in which case it returns nil.
+func NewCacheFromEnv() (Cache, error) {
+	switch strings.ToLower(os.Getenv("PLACES_CACHE")) {
+	case "", "memory":
+		// shared by every provider of the process, like the database
+		memoryCacheOnce.Do(func() {
+			size, err := strconv.Atoi(os.Getenv("PLACES_CACHE_SIZE"))
+			if err != nil || size <= 0 {
+				size = defaultMemoryCacheSize
+			}
+			memoryCache = NewMemoryCache(size)
+		})
+		return memoryCache, nil
+	case "postgres", "db":
+		return DBCache{}, nil
+	case "off", "none":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown PLACES_CACHE %q", os.Getenv("PLACES_CACHE"))
+	}
+}
+
+// CacheTTLFromEnv reads PLACES_CACHE_TTL_PHONE, PLACES_CACHE_TTL_WEBSITE,
+// PLACES_CACHE_TTL_HOURS and PLACES_CACHE_TTL_SEARCH as Go durations ("72h"), a plain
+// number is taken as days.
+func CacheTTLFromEnv() CacheTTL {
+	return CacheTTL{
+		Phone:   ttlFromEnv("PLACES_CACHE_TTL_PHONE", 7*24*time.Hour),
+		Website: ttlFromEnv("PLACES_CACHE_TTL_WEBSITE", 30*24*time.Hour),
+		Hours:   ttlFromEnv("PLACES_CACHE_TTL_HOURS", time.Hour),
+		Search:  ttlFromEnv("PLACES_CACHE_TTL_SEARCH", 24*time.Hour),
+	}
+}
+
+func ttlFromEnv(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if days, err := strconv.Atoi(value); err == nil {
+		return time.Duration(days) * 24 * time.Hour
+	}
+	if ttl, err := time.ParseDuration(value); err == nil {
+		return ttl
+	}
+	return fallback
+}
+
+// MemoryCache is a least recently used cache for local runs.
+type MemoryCache struct {
+	mutex    sync.Mutex
+	capacity int
+	order    *list.List // front is the most recently used
+	entries  map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key       string
+	value     string
+	expiresAt time.Time
+}
+
+func NewMemoryCache(capacity int) *MemoryCache {
+	return &MemoryCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+func (m *MemoryCache) Get(_ context.Context, key string) (string, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	element, ok := m.entries[key]
+	if !ok {
+		return "", false
+	}
+
+	entry := element.Value.(*memoryEntry)
+	if time.Now().After(entry.expiresAt) {
+		m.order.Remove(element)
+		delete(m.entries, key)
+		return "", false
+	}
+
+	m.order.MoveToFront(element)
+	return entry.value, true
+}
+
+func (m *MemoryCache) Set(_ context.Context, key string, value string, ttl time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+	if element, ok := m.entries[key]; ok {
+		entry := element.Value.(*memoryEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		m.order.MoveToFront(element)
+		return
+	}
+
+	m.entries[key] = m.order.PushFront(&memoryEntry{key: key, value: value, expiresAt: expiresAt})
+
+	for m.order.Len() > m.capacity {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryEntry).key)
+	}
+}
+
+// DBCache keeps entries in the place_cache_entries table so they are shared by every
+// Lambda instance. Errors are treated as misses, the cache must never fail a search.
+type DBCache struct{}
+
+func (DBCache) Get(ctx context.Context, key string) (string, bool) {
+	var entry models.PlaceCacheEntry
+	err := database.DB.WithContext(ctx).
+		Where("key = ? AND expires_at > ?", key, time.Now()).
+		Limit(1).Find(&entry).Error
+	if err != nil || entry.ID == 0 {
+		return "", false
+	}
+	return entry.Value, true
+}
+
+func (DBCache) Set(ctx context.Context, key string, value string, ttl time.Duration) {
+	entry := models.PlaceCacheEntry{Key: key, Value: value, ExpiresAt: time.Now().Add(ttl)}
+	database.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "expires_at", "updated_at"}),
+	}).Create(&entry)
+}
+
+// PurgeExpiredCache deletes expired entries from the place_cache_entries table.
+func PurgeExpiredCache() (int64, error) {
+	result := database.DB.Where("expires_at < ?", time.Now()).Delete(&models.PlaceCacheEntry{})
+	return result.RowsAffected, result.Error
+}
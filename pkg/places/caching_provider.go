@@ -0,0 +1,217 @@
+package places
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"medina-consultancy-api/pkg/textutil"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// cachedPageToken prefixes the page tokens handed out by CachingProvider. They carry
+	// the page number, used in the cache key, and the token of the wrapped provider for
+	// misses, left empty once that token may have expired.
+	cachedPageToken = "cached:"
+
+	// Google page tokens only work for a few minutes after the page was fetched
+	upstreamTokenLifetime = 2 * time.Minute
+)
+
+// CacheStats counts the cache lookups of a provider and is reported in the search meta.
+type CacheStats struct {
+	DetailsHits   int64 `json:"details_hits"`
+	DetailsMisses int64 `json:"details_misses"`
+	SearchHits    int64 `json:"search_hits"`
+	SearchMisses  int64 `json:"search_misses"`
+}
+
+// CachingProvider serves phones, websites, opening hours and search pages from a cache
+// before calling the wrapped provider. Details only returns the phone, website and
+// opening hours.
+type CachingProvider struct {
+	Provider PlaceProvider
+	Cache    Cache
+	TTL      CacheTTL
+
+	detailsHits   atomic.Int64
+	detailsMisses atomic.Int64
+	searchHits    atomic.Int64
+	searchMisses  atomic.Int64
+}
+
+func NewCachingProvider(provider PlaceProvider, cache Cache, ttl CacheTTL) *CachingProvider {
+	return &CachingProvider{Provider: provider, Cache: cache, TTL: ttl}
+}
+
+func (p *CachingProvider) Name() string {
+	return p.Provider.Name()
+}
+
+func (p *CachingProvider) Stats() CacheStats {
+	return CacheStats{
+		DetailsHits:   p.detailsHits.Load(),
+		DetailsMisses: p.detailsMisses.Load(),
+		SearchHits:    p.searchHits.Load(),
+		SearchMisses:  p.searchMisses.Load(),
+	}
+}
+
+func (p *CachingProvider) TextSearch(ctx context.Context, req SearchRequest) (*SearchPage, error) {
+	key := strings.Join([]string{"text", p.Name(), textutil.Fold(req.Query), textutil.CityKey(req.Location), req.PlaceType}, "|")
+
+	return p.cachedPage(ctx, key, req.PageToken, func(upstreamToken string) (*SearchPage, error) {
+		req.PageToken = upstreamToken
+		return p.Provider.TextSearch(ctx, req)
+	})
+}
+
+func (p *CachingProvider) NearbySearch(ctx context.Context, req NearbyRequest) (*SearchPage, error) {
+	// ~11m precision, grid tiles of the same area always produce the same centers
+	key := strings.Join([]string{
+		"nearby", p.Name(), textutil.Fold(req.Keyword),
+		strconv.FormatFloat(req.Location.Lat, 'f', 4, 64),
+		strconv.FormatFloat(req.Location.Lng, 'f', 4, 64),
+		strconv.FormatFloat(req.RadiusMeters, 'f', 0, 64),
+		req.PlaceType,
+	}, "|")
+
+	return p.cachedPage(ctx, key, req.PageToken, func(upstreamToken string) (*SearchPage, error) {
+		req.PageToken = upstreamToken
+		return p.Provider.NearbySearch(ctx, req)
+	})
+}
+
+type cachedPage struct {
+	Results       []PlaceDetails `json:"results"`
+	NextPageToken string         `json:"next_page_token"` // of the wrapped provider
+	FetchedAt     time.Time      `json:"fetched_at"`
+}
+
+func (p *CachingProvider) cachedPage(ctx context.Context, key string, pageToken string, fetch func(upstreamToken string) (*SearchPage, error)) (*SearchPage, error) {
+	pageNumber, upstreamToken, err := parseCachedPageToken(pageToken)
+	if err != nil {
+		return nil, err
+	}
+
+	pageKey := fmt.Sprintf("%s|page%d", key, pageNumber)
+
+	if value, ok := p.Cache.Get(ctx, pageKey); ok {
+		var page cachedPage
+		if err := json.Unmarshal([]byte(value), &page); err == nil {
+			p.searchHits.Add(1)
+			return &SearchPage{Results: page.Results, NextPageToken: nextCachedPageToken(pageNumber, page)}, nil
+		}
+	}
+
+	p.searchMisses.Add(1)
+
+	// the token of a cached page expired, the provider only gets to this page again
+	// from the first one
+	if pageNumber > 0 && upstreamToken == "" {
+		return p.replayPages(ctx, key, pageNumber, fetch)
+	}
+
+	page, err := fetch(upstreamToken)
+	if err != nil {
+		return nil, err
+	}
+
+	cached := p.storePage(ctx, pageKey, page)
+	return &SearchPage{Results: page.Results, NextPageToken: nextCachedPageToken(pageNumber, cached)}, nil
+}
+
+// replayPages fetches the pages of a query from the first one up to pageNumber, caching
+// them along the way.
+func (p *CachingProvider) replayPages(ctx context.Context, key string, pageNumber int, fetch func(upstreamToken string) (*SearchPage, error)) (*SearchPage, error) {
+	upstreamToken := ""
+	for number := 0; ; number++ {
+		page, err := fetch(upstreamToken)
+		if err != nil {
+			return nil, err
+		}
+
+		cached := p.storePage(ctx, fmt.Sprintf("%s|page%d", key, number), page)
+		if number == pageNumber {
+			return &SearchPage{Results: page.Results, NextPageToken: nextCachedPageToken(pageNumber, cached)}, nil
+		}
+
+		// the query returns fewer pages than before
+		if page.NextPageToken == "" {
+			return &SearchPage{}, nil
+		}
+		upstreamToken = page.NextPageToken
+	}
+}
+
+func (p *CachingProvider) storePage(ctx context.Context, pageKey string, page *SearchPage) cachedPage {
+	cached := cachedPage{Results: page.Results, NextPageToken: page.NextPageToken, FetchedAt: time.Now()}
+	if value, err := json.Marshal(cached); err == nil {
+		p.Cache.Set(ctx, pageKey, string(value), p.TTL.Search)
+	}
+	return cached
+}
+
+// nextCachedPageToken points to the page after a cached one, without the upstream token
+// once it may have expired.
+func nextCachedPageToken(pageNumber int, page cachedPage) string {
+	if page.NextPageToken == "" {
+		return ""
+	}
+	upstreamToken := page.NextPageToken
+	if time.Since(page.FetchedAt) > upstreamTokenLifetime {
+		upstreamToken = ""
+	}
+	return fmt.Sprintf("%s%d:%s", cachedPageToken, pageNumber+1, upstreamToken)
+}
+
+func parseCachedPageToken(token string) (pageNumber int, upstreamToken string, err error) {
+	if token == "" {
+		return 0, "", nil
+	}
+
+	number, upstream, found := strings.Cut(strings.TrimPrefix(token, cachedPageToken), ":")
+	pageNumber, convErr := strconv.Atoi(number)
+	if !strings.HasPrefix(token, cachedPageToken) || !found || convErr != nil {
+		return 0, "", fmt.Errorf("invalid page token %q", token)
+	}
+
+	return pageNumber, upstream, nil
+}
+
+func (p *CachingProvider) Details(ctx context.Context, placeID string) (*PlaceDetails, error) {
+	phoneKey := "phone|" + p.Name() + "|" + placeID
+	websiteKey := "website|" + p.Name() + "|" + placeID
+	hoursKey := "hours|" + p.Name() + "|" + placeID
+
+	// empty values are cached too, most places have no website
+	phone, phoneOK := p.Cache.Get(ctx, phoneKey)
+	website, websiteOK := p.Cache.Get(ctx, websiteKey)
+	if phoneOK && websiteOK {
+		p.detailsHits.Add(1)
+		// hours expire first and are left out once they do, the stored ones are kept
+		details := &PlaceDetails{PlaceID: placeID, FormattedPhoneNumber: phone, Website: website}
+		if value, ok := p.Cache.Get(ctx, hoursKey); ok {
+			json.Unmarshal([]byte(value), &details.OpeningHours)
+		}
+		return details, nil
+	}
+
+	p.detailsMisses.Add(1)
+
+	details, err := p.Provider.Details(ctx, placeID)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Cache.Set(ctx, phoneKey, details.FormattedPhoneNumber, p.TTL.Phone)
+	p.Cache.Set(ctx, websiteKey, details.Website, p.TTL.Website)
+	if hours, err := json.Marshal(details.OpeningHours); err == nil {
+		p.Cache.Set(ctx, hoursKey, string(hours), p.TTL.Hours)
+	}
+
+	return details, nil
+}
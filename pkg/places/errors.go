@@ -0,0 +1,54 @@
+package places
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ProviderError is a request the provider answered with an error status, such as
+// OVER_QUERY_LIMIT or HTTP 503.
+type ProviderError struct {
+	Provider string
+	Status   string
+	Message  string
+}
+
+func (e *ProviderError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s returned %s", e.Provider, e.Status)
+	}
+	return fmt.Sprintf("%s returned %s: %s", e.Provider, e.Status, e.Message)
+}
+
+// ErrorMessage describes an error returned by a provider for the search warnings, which
+// are shown to users and stored. Only what the provider reported is kept, request errors
+// may carry URLs with API keys.
+func ErrorMessage(err error) string {
+	var providerErr *ProviderError
+	switch {
+	case errors.As(err, &providerErr):
+		return providerErr.Error()
+	case errors.Is(err, context.DeadlineExceeded):
+		return "request timed out"
+	case errors.Is(err, context.Canceled):
+		return "request canceled"
+	default:
+		return "request to the provider failed"
+	}
+}
+
+// ErrorStatus classifies an error returned by a provider for reporting.
+func ErrorStatus(err error) string {
+	var providerErr *ProviderError
+	switch {
+	case errors.As(err, &providerErr):
+		return providerErr.Status
+	case errors.Is(err, context.DeadlineExceeded):
+		return "TIMEOUT"
+	case errors.Is(err, context.Canceled):
+		return "CANCELED"
+	default:
+		return "REQUEST_FAILED"
+	}
+}
@@ -0,0 +1,131 @@
+package places
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"medina-consultancy-api/pkg/geo"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const fixturePageSize = 20
+
+// FixtureProvider serves places from a local JSON file (an array of PlaceDetails) so the
+// search pipeline can run offline. Places match when any query word appears in their
+// name, address or types; text search ignores the location and nearby search keeps
+// places within the radius.
+type FixtureProvider struct {
+	Places []PlaceDetails
+}
+
+func NewFixtureProvider(path string) (*FixtureProvider, error) {
+	if path == "" {
+		return nil, fmt.Errorf("PLACES_FIXTURE_PATH is not set")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture file: %w", err)
+	}
+
+	var fixturePlaces []PlaceDetails
+	if err := json.Unmarshal(data, &fixturePlaces); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture file: %w", err)
+	}
+
+	return &FixtureProvider{Places: fixturePlaces}, nil
+}
+
+func (p *FixtureProvider) Name() string {
+	return "fixture"
+}
+
+func (p *FixtureProvider) TextSearch(ctx context.Context, req SearchRequest) (*SearchPage, error) {
+	var matches []PlaceDetails
+	for _, place := range p.Places {
+		if req.PlaceType != "" && !containsString(place.Types, req.PlaceType) {
+			continue
+		}
+		if fixtureMatches(place, req.Query) {
+			matches = append(matches, place)
+		}
+	}
+
+	return fixturePage(matches, req.PageToken)
+}
+
+func (p *FixtureProvider) NearbySearch(ctx context.Context, req NearbyRequest) (*SearchPage, error) {
+	var matches []PlaceDetails
+	for _, place := range p.Places {
+		if req.PlaceType != "" && !containsString(place.Types, req.PlaceType) {
+			continue
+		}
+		if geo.Distance(req.Location, geo.LatLng{Lat: place.Lat, Lng: place.Lng}) > req.RadiusMeters {
+			continue
+		}
+		if fixtureMatches(place, req.Keyword) {
+			matches = append(matches, place)
+		}
+	}
+
+	return fixturePage(matches, req.PageToken)
+}
+
+func fixturePage(matches []PlaceDetails, pageToken string) (*SearchPage, error) {
+	offset := 0
+	if pageToken != "" {
+		var err error
+		if offset, err = strconv.Atoi(pageToken); err != nil {
+			return nil, fmt.Errorf("invalid page token %q", pageToken)
+		}
+	}
+
+	end := min(offset+fixturePageSize, len(matches))
+	page := &SearchPage{}
+	if offset < end {
+		page.Results = matches[offset:end]
+	}
+	if end < len(matches) {
+		page.NextPageToken = strconv.Itoa(end)
+	}
+
+	return page, nil
+}
+
+func (p *FixtureProvider) Details(ctx context.Context, placeID string) (*PlaceDetails, error) {
+	for _, place := range p.Places {
+		if place.PlaceID == placeID {
+			details := place
+			return &details, nil
+		}
+	}
+
+	return nil, fmt.Errorf("place %s not found", placeID)
+}
+
+func fixtureMatches(place PlaceDetails, query string) bool {
+	haystack := strings.ToLower(strings.Join(append([]string{place.Name, place.FormattedAddress}, place.Types...), " "))
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return true
+	}
+
+	for _, word := range words {
+		if strings.Contains(haystack, word) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
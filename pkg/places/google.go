@@ -0,0 +1,212 @@
+package places
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"medina-consultancy-api/pkg/geo"
+	"medina-consultancy-api/pkg/outbound"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	googleTextSearchURL = "https://maps.googleapis.com/maps/api/place/textsearch/json"
+	googleNearbyURL     = "https://maps.googleapis.com/maps/api/place/nearbysearch/json"
+	googleDetailsURL    = "https://maps.googleapis.com/maps/api/place/details/json"
+
+	// next_page_token only becomes valid a short time after it is issued
+	googlePageTokenDelay = 2 * time.Second
+)
+
+// googleClient is shared by every Google provider of the process, the QPS limit is per
+// API key and not per search.
+var googleClient = sync.OnceValue(func() *outbound.Client {
+	return outbound.NewClient(outbound.ConfigFromEnv("GOOGLE_PLACES", outbound.Config{
+		RequestsPerSecond: 10,
+		Burst:             10,
+		MaxConcurrent:     8,
+		Timeout:           10 * time.Second,
+		MaxRetries:        3,
+		Backoff:           500 * time.Millisecond,
+	}))
+})
+
+type GoogleProvider struct {
+	APIKey string
+	Client *outbound.Client
+}
+
+func NewGoogleProvider(apiKey string) (*GoogleProvider, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("GOOGLE_PLACES_API_KEY is not set")
+	}
+
+	return &GoogleProvider{
+		APIKey: apiKey,
+		Client: googleClient(),
+	}, nil
+}
+
+func (p *GoogleProvider) Name() string {
+	return "google"
+}
+
+func (p *GoogleProvider) TextSearch(ctx context.Context, req SearchRequest) (*SearchPage, error) {
+	params := url.Values{}
+	params.Add("query", fmt.Sprintf("%s in %s", req.Query, req.Location))
+	params.Add("key", p.APIKey)
+
+	if req.PlaceType != "" {
+		params.Add("type", req.PlaceType)
+	}
+
+	return p.search(ctx, googleTextSearchURL, params, req.PageToken)
+}
+
+func (p *GoogleProvider) NearbySearch(ctx context.Context, req NearbyRequest) (*SearchPage, error) {
+	params := url.Values{}
+	params.Add("location", fmt.Sprintf("%f,%f", req.Location.Lat, req.Location.Lng))
+	params.Add("radius", fmt.Sprintf("%.0f", req.RadiusMeters))
+	params.Add("keyword", req.Keyword)
+	params.Add("key", p.APIKey)
+
+	if req.PlaceType != "" {
+		params.Add("type", req.PlaceType)
+	}
+
+	return p.search(ctx, googleNearbyURL, params, req.PageToken)
+}
+
+// search runs a Text Search or Nearby Search request, both share the same response format
+// except that Nearby Search returns vicinity instead of formatted_address.
+func (p *GoogleProvider) search(ctx context.Context, baseURL string, params url.Values, pageToken string) (*SearchPage, error) {
+	if pageToken != "" {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(googlePageTokenDelay):
+		}
+		params.Add("pagetoken", pageToken)
+	}
+
+	body, err := p.get(ctx, baseURL, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var placesResponse struct {
+		Results []struct {
+			PlaceID          string   `json:"place_id"`
+			Name             string   `json:"name"`
+			FormattedAddress string   `json:"formatted_address"`
+			Vicinity         string   `json:"vicinity"`
+			Rating           float64  `json:"rating"`
+			UserRatingsTotal int      `json:"user_ratings_total"`
+			PriceLevel       int      `json:"price_level"`
+			BusinessStatus   string   `json:"business_status"`
+			Types            []string `json:"types"`
+			Geometry         struct {
+				Location geo.LatLng `json:"location"`
+			} `json:"geometry"`
+		} `json:"results"`
+		NextPageToken string `json:"next_page_token"`
+		Status        string `json:"status"`
+		ErrorMessage  string `json:"error_message"`
+	}
+
+	if err := json.Unmarshal(body, &placesResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	if placesResponse.Status != "OK" && placesResponse.Status != "ZERO_RESULTS" {
+		return nil, &ProviderError{Provider: p.Name(), Status: placesResponse.Status, Message: placesResponse.ErrorMessage}
+	}
+
+	page := &SearchPage{NextPageToken: placesResponse.NextPageToken}
+	for _, result := range placesResponse.Results {
+		address := result.FormattedAddress
+		if address == "" {
+			address = result.Vicinity
+		}
+
+		page.Results = append(page.Results, PlaceDetails{
+			PlaceID:          result.PlaceID,
+			Name:             result.Name,
+			FormattedAddress: address,
+			URL:              fmt.Sprintf("https://www.google.com/maps/place/?q=place_id:%s", result.PlaceID),
+			Rating:           result.Rating,
+			UserRatingsTotal: result.UserRatingsTotal,
+			PriceLevel:       result.PriceLevel,
+			BusinessStatus:   result.BusinessStatus,
+			Types:            result.Types,
+			Lat:              result.Geometry.Location.Lat,
+			Lng:              result.Geometry.Location.Lng,
+		})
+	}
+
+	return page, nil
+}
+
+func (p *GoogleProvider) Details(ctx context.Context, placeID string) (*PlaceDetails, error) {
+	params := url.Values{}
+	params.Add("place_id", placeID)
+	params.Add("fields", "formatted_phone_number,website,opening_hours")
+	params.Add("key", p.APIKey)
+
+	body, err := p.get(ctx, googleDetailsURL, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var detailsResponse struct {
+		Result struct {
+			FormattedPhoneNumber string        `json:"formatted_phone_number"`
+			Website              string        `json:"website"`
+			OpeningHours         *OpeningHours `json:"opening_hours"`
+		} `json:"result"`
+		Status string `json:"status"`
+	}
+
+	if err := json.Unmarshal(body, &detailsResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	if detailsResponse.Status != "OK" {
+		return nil, &ProviderError{Provider: p.Name(), Status: detailsResponse.Status}
+	}
+
+	return &PlaceDetails{
+		PlaceID:              placeID,
+		FormattedPhoneNumber: detailsResponse.Result.FormattedPhoneNumber,
+		Website:              detailsResponse.Result.Website,
+		OpeningHours:         detailsResponse.Result.OpeningHours,
+	}, nil
+}
+
+func (p *GoogleProvider) get(ctx context.Context, baseURL string, params url.Values) ([]byte, error) {
+	status, body, err := p.Client.Get(ctx, fmt.Sprintf("%s?%s", baseURL, params.Encode()), nil, retryGoogle)
+	if err != nil {
+		return nil, err
+	}
+
+	if status != http.StatusOK {
+		return nil, &ProviderError{Provider: p.Name(), Status: fmt.Sprintf("HTTP %d", status), Message: string(body[:min(200, len(body))])}
+	}
+
+	return body, nil
+}
+
+// retryGoogle retries server errors and OVER_QUERY_LIMIT, which Google answers with 200.
+func retryGoogle(status int, body []byte) bool {
+	if outbound.RetryServerErrors(status, body) {
+		return true
+	}
+
+	var response struct {
+		Status string `json:"status"`
+	}
+	return json.Unmarshal(body, &response) == nil && response.Status == "OVER_QUERY_LIMIT"
+}
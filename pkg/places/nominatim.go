@@ -0,0 +1,209 @@
+package places
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"medina-consultancy-api/pkg/geo"
+	"medina-consultancy-api/pkg/outbound"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultNominatimURL       = "https://nominatim.openstreetmap.org"
+	defaultNominatimUserAgent = "medina-consultancy-api"
+	nominatimPageSize         = 40
+)
+
+// NominatimProvider searches OpenStreetMap data through a Nominatim instance.
+// Place IDs are OSM references such as "N123456" so they can be passed to /lookup.
+type NominatimProvider struct {
+	BaseURL   string
+	UserAgent string
+	Client    *outbound.Client
+}
+
+// nominatimClient follows the public instance usage policy of one request per second.
+var nominatimClient = sync.OnceValue(func() *outbound.Client {
+	return outbound.NewClient(outbound.ConfigFromEnv("NOMINATIM", outbound.Config{
+		RequestsPerSecond: 1,
+		Burst:             1,
+		MaxConcurrent:     1,
+		Timeout:           15 * time.Second,
+		MaxRetries:        2,
+		Backoff:           time.Second,
+	}))
+})
+
+type nominatimResult struct {
+	PlaceID     int64             `json:"place_id"`
+	OSMType     string            `json:"osm_type"`
+	OSMID       int64             `json:"osm_id"`
+	Lat         string            `json:"lat"`
+	Lon         string            `json:"lon"`
+	Name        string            `json:"name"`
+	DisplayName string            `json:"display_name"`
+	Category    string            `json:"category"`
+	Type        string            `json:"type"`
+	ExtraTags   map[string]string `json:"extratags"`
+}
+
+func NewNominatimProvider(baseURL string, userAgent string) (*NominatimProvider, error) {
+	if baseURL == "" {
+		baseURL = defaultNominatimURL
+	}
+	if userAgent == "" {
+		userAgent = defaultNominatimUserAgent
+	}
+
+	return &NominatimProvider{
+		BaseURL:   strings.TrimRight(baseURL, "/"),
+		UserAgent: userAgent,
+		Client:    nominatimClient(),
+	}, nil
+}
+
+func (p *NominatimProvider) Name() string {
+	return "osm"
+}
+
+// TextSearch pages through results using exclude_place_ids; the page token is the
+// comma-separated list of Nominatim place IDs already returned.
+// TextSearch pages through results using exclude_place_ids; the page token is the
+// comma-separated list of Nominatim place IDs already returned.
+func (p *NominatimProvider) TextSearch(ctx context.Context, req SearchRequest) (*SearchPage, error) {
+	params := url.Values{}
+	params.Add("q", strings.TrimSpace(fmt.Sprintf("%s %s", req.Query, req.Location)))
+
+	return p.search(ctx, params, req.PageToken)
+}
+
+// NearbySearch restricts the query to the bounding box of the search circle.
+func (p *NominatimProvider) NearbySearch(ctx context.Context, req NearbyRequest) (*SearchPage, error) {
+	box := geo.CircleBounds(req.Location, req.RadiusMeters)
+
+	params := url.Values{}
+	params.Add("q", req.Keyword)
+	params.Add("viewbox", fmt.Sprintf("%f,%f,%f,%f", box.West, box.North, box.East, box.South))
+	params.Add("bounded", "1")
+
+	return p.search(ctx, params, req.PageToken)
+}
+
+func (p *NominatimProvider) search(ctx context.Context, params url.Values, pageToken string) (*SearchPage, error) {
+	params.Add("format", "jsonv2")
+	params.Add("extratags", "1")
+	params.Add("limit", fmt.Sprintf("%d", nominatimPageSize))
+
+	if pageToken != "" {
+		params.Add("exclude_place_ids", pageToken)
+	}
+
+	var results []nominatimResult
+	if err := p.get(ctx, "/search", params, &results); err != nil {
+		return nil, err
+	}
+
+	page := &SearchPage{}
+	seen := []string{}
+	if pageToken != "" {
+		seen = strings.Split(pageToken, ",")
+	}
+
+	for _, result := range results {
+		seen = append(seen, fmt.Sprintf("%d", result.PlaceID))
+		page.Results = append(page.Results, result.toPlaceDetails())
+	}
+
+	if len(results) == nominatimPageSize {
+		page.NextPageToken = strings.Join(seen, ",")
+	}
+
+	return page, nil
+}
+
+func (p *NominatimProvider) Details(ctx context.Context, placeID string) (*PlaceDetails, error) {
+	params := url.Values{}
+	params.Add("osm_ids", placeID)
+	params.Add("format", "jsonv2")
+	params.Add("extratags", "1")
+
+	var results []nominatimResult
+	if err := p.get(ctx, "/lookup", params, &results); err != nil {
+		return nil, err
+	}
+
+	if len(results) == 0 {
+		return nil, fmt.Errorf("place %s not found", placeID)
+	}
+
+	details := results[0].toPlaceDetails()
+	return &details, nil
+}
+
+func (p *NominatimProvider) get(ctx context.Context, path string, params url.Values, out interface{}) error {
+	// a User-Agent is required by the Nominatim usage policy
+	header := http.Header{"User-Agent": []string{p.UserAgent}}
+
+	status, body, err := p.Client.Get(ctx, fmt.Sprintf("%s%s?%s", p.BaseURL, path, params.Encode()), header, outbound.RetryServerErrors)
+	if err != nil {
+		return err
+	}
+
+	if status != http.StatusOK {
+		return &ProviderError{Provider: p.Name(), Status: fmt.Sprintf("HTTP %d", status), Message: string(body[:min(200, len(body))])}
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	return nil
+}
+
+func (r nominatimResult) toPlaceDetails() PlaceDetails {
+	osmRef := fmt.Sprintf("%s%d", strings.ToUpper(r.OSMType[:min(1, len(r.OSMType))]), r.OSMID)
+
+	name := r.Name
+	if name == "" {
+		name = strings.Split(r.DisplayName, ",")[0]
+	}
+
+	phone := r.ExtraTags["phone"]
+	if phone == "" {
+		phone = r.ExtraTags["contact:phone"]
+	}
+
+	website := r.ExtraTags["website"]
+	if website == "" {
+		website = r.ExtraTags["contact:website"]
+	}
+
+	lat, _ := strconv.ParseFloat(r.Lat, 64)
+	lng, _ := strconv.ParseFloat(r.Lon, 64)
+
+	// OSM keeps the hours as rules ("Mo-Fr 08:00-18:00; Sa 08:00-12:00"), open now is
+	// not known
+	var hours *OpeningHours
+	if rules := r.ExtraTags["opening_hours"]; rules != "" {
+		hours = &OpeningHours{WeekdayText: strings.Split(rules, "; ")}
+	}
+
+	return PlaceDetails{
+		PlaceID:              osmRef,
+		Name:                 name,
+		FormattedAddress:     r.DisplayName,
+		FormattedPhoneNumber: phone,
+		Website:              website,
+		URL:                  fmt.Sprintf("https://www.openstreetmap.org/%s/%d", r.OSMType, r.OSMID),
+		OpeningHours:         hours,
+		Types:                []string{r.Type},
+		Lat:                  lat,
+		Lng:                  lng,
+	}
+}
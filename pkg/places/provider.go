@@ -0,0 +1,107 @@
+package places
+
+import (
+	"context"
+	"fmt"
+	"medina-consultancy-api/pkg/geo"
+	"os"
+	"strings"
+	"time"
+)
+
+type PlaceDetails struct {
+	PlaceID              string        `json:"place_id"`
+	Name                 string        `json:"name"`
+	FormattedAddress     string        `json:"formatted_address"`
+	FormattedPhoneNumber string        `json:"formatted_phone_number"`
+	Website              string        `json:"website"`
+	URL                  string        `json:"url"`
+	Rating               float64       `json:"rating"`
+	UserRatingsTotal     int           `json:"user_ratings_total"`
+	PriceLevel           int           `json:"price_level"`
+	BusinessStatus       string        `json:"business_status"`
+	OpeningHours         *OpeningHours `json:"opening_hours"`
+	Types                []string      `json:"types"`
+	Lat                  float64       `json:"lat"`
+	Lng                  float64       `json:"lng"`
+
+	// FormattedPhoneNumber normalized for the country searched
+	PhoneE164      string `json:"phone_e164,omitempty"`
+	PhoneType      string `json:"phone_type,omitempty"` // mobile, landline or unknown
+	WhatsAppLikely bool   `json:"whatsapp_likely"`
+
+	// contacts found on the website, set only when the search asked for enrichment
+	Emails        []string          `json:"emails,omitempty"`
+	WebsitePhones []string          `json:"website_phones,omitempty"`
+	SocialLinks   map[string]string `json:"social_links,omitempty"` // by network, e.g. instagram
+	WhatsApp      string            `json:"whatsapp,omitempty"`     // wa.me link
+	EnrichedAt    *time.Time        `json:"enriched_at,omitempty"`
+}
+
+type OpeningHours struct {
+	OpenNow     bool     `json:"open_now"`
+	WeekdayText []string `json:"weekday_text"`
+}
+
+type SearchRequest struct {
+	Query     string
+	Location  string
+	PlaceType string
+	PageToken string // empty for the first page
+}
+
+type NearbyRequest struct {
+	Keyword      string
+	Location     geo.LatLng
+	RadiusMeters float64
+	PlaceType    string
+	PageToken    string // empty for the first page
+}
+
+type SearchPage struct {
+	Results       []PlaceDetails
+	NextPageToken string // empty when there are no more pages
+}
+
+// PlaceProvider is a source of places. TextSearch and NearbySearch return one page of
+// results at a time and Details fills the fields (phone, website, opening hours) that are
+// not part of the search results.
+type PlaceProvider interface {
+	Name() string
+	TextSearch(ctx context.Context, req SearchRequest) (*SearchPage, error)
+	NearbySearch(ctx context.Context, req NearbyRequest) (*SearchPage, error)
+	Details(ctx context.Context, placeID string) (*PlaceDetails, error)
+}
+
+// NewProviderFromEnv selects the provider from PLACES_PROVIDER (google, osm or fixture)
+// and wraps it with the cache configured in PLACES_CACHE. Google is used when the
+// variable is not set.
+func NewProviderFromEnv() (PlaceProvider, error) {
+	provider, err := newBaseProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	cache, err := NewCacheFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if cache == nil {
+		return provider, nil
+	}
+
+	return NewCachingProvider(provider, cache, CacheTTLFromEnv()), nil
+}
+
+func newBaseProvider() (PlaceProvider, error) {
+	switch strings.ToLower(os.Getenv("PLACES_PROVIDER")) {
+	case "", "google":
+		return NewGoogleProvider(os.Getenv("GOOGLE_PLACES_API_KEY"))
+	case "osm", "nominatim":
+		return NewNominatimProvider(os.Getenv("NOMINATIM_URL"), os.Getenv("NOMINATIM_USER_AGENT"))
+	case "fixture":
+		return NewFixtureProvider(os.Getenv("PLACES_FIXTURE_PATH"))
+	default:
+		return nil, fmt.Errorf("unknown PLACES_PROVIDER %q", os.Getenv("PLACES_PROVIDER"))
+	}
+}
@@ -0,0 +1,81 @@
+package pricing
+
+import (
+	"fmt"
+	"medina-consultancy-api/database"
+	"medina-consultancy-api/models"
+	"os"
+	"strconv"
+)
+
+const (
+	defaultStandardCredits   = 10
+	defaultStandardQueries   = 6
+	defaultMinimumCredits    = 5
+	defaultEnrichmentCredits = 5
+)
+
+// Default is the pricing in effect until an admin saves one: 10 credits for the 6 queries
+// of a standard search, 5 at least, plus ENRICHMENT_CREDITS for enrichment.
+func Default() models.PricingConfig {
+	config := models.PricingConfig{
+		StandardCredits:   defaultStandardCredits,
+		StandardQueries:   defaultStandardQueries,
+		MinimumCredits:    defaultMinimumCredits,
+		EnrichmentCredits: defaultEnrichmentCredits,
+	}
+	if credits, err := strconv.Atoi(os.Getenv("ENRICHMENT_CREDITS")); err == nil && credits >= 0 {
+		config.EnrichmentCredits = credits
+	}
+	return config
+}
+
+// Current returns the latest pricing saved by an admin, or Default.
+func Current() (models.PricingConfig, error) {
+	var config models.PricingConfig
+	if err := database.DB.Order("id DESC").Limit(1).Find(&config).Error; err != nil {
+		return config, fmt.Errorf("failed to load pricing: %w", err)
+	}
+	if config.ID == 0 {
+		return Default(), nil
+	}
+	return config, nil
+}
+
+// Validate rejects pricing that cannot be quoted.
+func Validate(config models.PricingConfig) error {
+	if config.StandardQueries <= 0 {
+		return fmt.Errorf("standard_queries must be positive")
+	}
+	if config.StandardCredits < 0 || config.MinimumCredits < 0 || config.DetailsCredits < 0 ||
+		config.EnrichmentCredits < 0 || config.ResultCredits < 0 || config.ResultCreditsCap < 0 {
+		return fmt.Errorf("credits cannot be negative")
+	}
+	// the cap is debited up front, searches cannot reserve an unbounded amount
+	if config.ResultCredits > 0 && config.ResultCreditsCap == 0 {
+		return fmt.Errorf("result_credits_cap is required with result_credits")
+	}
+	return nil
+}
+
+// Quote prices a search sending the given number of queries to the provider, with or
+// without the place details and the website enrichment.
+func Quote(config models.PricingConfig, queries int, details bool, enrichment bool) models.CreditQuote {
+	standard := (config.StandardCredits*queries + config.StandardQueries - 1) / config.StandardQueries
+
+	quote := models.CreditQuote{
+		Queries: queries,
+		Search:  max(standard, config.MinimumCredits),
+	}
+	if details {
+		quote.Details = config.DetailsCredits
+	}
+	if enrichment {
+		quote.Enrichment = config.EnrichmentCredits
+	}
+	if config.ResultCredits > 0 {
+		quote.ResultCredits = config.ResultCredits
+		quote.ResultCap = config.ResultCreditsCap
+	}
+	return quote
+}
@@ -0,0 +1,149 @@
+package pricing
+
+import (
+	"medina-consultancy-api/models"
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	config := models.PricingConfig{
+		StandardCredits:   10,
+		StandardQueries:   6,
+		MinimumCredits:    5,
+		DetailsCredits:    2,
+		EnrichmentCredits: 5,
+	}
+	perResult := config
+	perResult.ResultCredits = 0.5
+	perResult.ResultCreditsCap = 20
+
+	tests := []struct {
+		name       string
+		config     models.PricingConfig
+		queries    int
+		details    bool
+		enrichment bool
+		want       models.CreditQuote
+	}{
+		{"standard", config, 6, false, false, models.CreditQuote{Queries: 6, Search: 10}},
+		{"rounded up", config, 7, false, false, models.CreditQuote{Queries: 7, Search: 12}},
+		{"exhaustive", config, 40, false, false, models.CreditQuote{Queries: 40, Search: 67}},
+		{"minimum", config, 1, false, false, models.CreditQuote{Queries: 1, Search: 5}},
+		{"no queries", config, 0, false, false, models.CreditQuote{Search: 5}},
+		{"details", config, 6, true, false, models.CreditQuote{Queries: 6, Search: 10, Details: 2}},
+		{"enrichment", config, 6, true, true, models.CreditQuote{Queries: 6, Search: 10, Details: 2, Enrichment: 5}},
+		{"per result", perResult, 6, false, false, models.CreditQuote{Queries: 6, Search: 10, ResultCredits: 0.5, ResultCap: 20}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Quote(tt.config, tt.queries, tt.details, tt.enrichment); got != tt.want {
+				t.Errorf("Quote = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreditQuoteCharge(t *testing.T) {
+	quote := models.CreditQuote{Search: 10, Details: 2, Enrichment: 5, ResultCredits: 0.3, ResultCap: 20}
+
+	if got := quote.Base(); got != 17 {
+		t.Errorf("Base = %d, want 17", got)
+	}
+	if got := quote.Reserved(); got != 37 {
+		t.Errorf("Reserved = %d, want 37", got)
+	}
+
+	tests := []struct {
+		results int
+		charge  int
+	}{
+		{0, 0},
+		{1, 1},  // 0.3 rounded up
+		{10, 3}, // exactly 3
+		{11, 4}, // 3.3 rounded up
+		{66, 20},
+		{67, 20}, // 20.1, capped
+		{1000, 20},
+	}
+
+	for _, tt := range tests {
+		if got := quote.ResultCharge(tt.results); got != tt.charge {
+			t.Errorf("ResultCharge(%d) = %d, want %d", tt.results, got, tt.charge)
+		}
+		if got := quote.Charge(tt.results); got != quote.Base()+tt.charge {
+			t.Errorf("Charge(%d) = %d, want %d", tt.results, got, quote.Base()+tt.charge)
+		}
+	}
+
+	// products that are not exact in floating point
+	uncapped := models.CreditQuote{ResultCredits: 1.1, ResultCap: 1000}
+	if got := uncapped.ResultCharge(100); got != 110 {
+		t.Errorf("ResultCharge(100) at 1.1 = %d, want 110", got)
+	}
+	if got := uncapped.ResultCharge(3); got != 4 {
+		t.Errorf("ResultCharge(3) at 1.1 = %d, want 4", got)
+	}
+
+	flat := models.CreditQuote{Search: 10}
+	if got := flat.ResultCharge(500); got != 0 {
+		t.Errorf("ResultCharge without result credits = %d, want 0", got)
+	}
+	if got := flat.Reserved(); got != 10 {
+		t.Errorf("Reserved without result credits = %d, want 10", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := Default()
+
+	tests := []struct {
+		name    string
+		change  func(*models.PricingConfig)
+		wantErr bool
+	}{
+		{"default", func(*models.PricingConfig) {}, false},
+		{"per result with cap", func(c *models.PricingConfig) { c.ResultCredits, c.ResultCreditsCap = 0.2, 30 }, false},
+		{"free searches", func(c *models.PricingConfig) { c.StandardCredits, c.MinimumCredits = 0, 0 }, false},
+		{"no standard queries", func(c *models.PricingConfig) { c.StandardQueries = 0 }, true},
+		{"negative standard queries", func(c *models.PricingConfig) { c.StandardQueries = -6 }, true},
+		{"negative standard credits", func(c *models.PricingConfig) { c.StandardCredits = -1 }, true},
+		{"negative minimum", func(c *models.PricingConfig) { c.MinimumCredits = -1 }, true},
+		{"negative details", func(c *models.PricingConfig) { c.DetailsCredits = -1 }, true},
+		{"negative enrichment", func(c *models.PricingConfig) { c.EnrichmentCredits = -1 }, true},
+		{"negative result credits", func(c *models.PricingConfig) { c.ResultCredits = -0.5 }, true},
+		{"negative cap", func(c *models.PricingConfig) { c.ResultCreditsCap = -1 }, true},
+		{"per result without cap", func(c *models.PricingConfig) { c.ResultCredits = 0.2 }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := valid
+			tt.change(&config)
+			if err := Validate(config); (err != nil) != tt.wantErr {
+				t.Errorf("Validate error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDefault(t *testing.T) {
+	t.Setenv("ENRICHMENT_CREDITS", "")
+	config := Default()
+	if err := Validate(config); err != nil {
+		t.Fatalf("Default is invalid: %v", err)
+	}
+	if got := Quote(config, 6, true, true).Reserved(); got != 15 {
+		t.Errorf("standard search with enrichment = %d credits, want 15", got)
+	}
+
+	t.Setenv("ENRICHMENT_CREDITS", "8")
+	if got := Default().EnrichmentCredits; got != 8 {
+		t.Errorf("EnrichmentCredits = %d, want 8 from ENRICHMENT_CREDITS", got)
+	}
+
+	t.Setenv("ENRICHMENT_CREDITS", "-1")
+	if got := Default().EnrichmentCredits; got != defaultEnrichmentCredits {
+		t.Errorf("EnrichmentCredits = %d, want the default for a negative value", got)
+	}
+}
@@ -0,0 +1,173 @@
+package reconciliation
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"medina-consultancy-api/database"
+	"medina-consultancy-api/models"
+	mercadopago "medina-consultancy-api/pkg/mercado_pago"
+	"medina-consultancy-api/pkg/payments"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultPixExpiration = 24 * time.Hour
+
+	// stop calling Mercado Pago when the invocation deadline is this close, leaving time
+	// to save the report
+	deadlineMargin = 30 * time.Second
+)
+
+// ReconcilePendingOrders compares every unsettled order with Mercado Pago, applies the
+// status it reports, credits approved orders and expires Pix charges past their deadline.
+// Invoices whose card charge is still processing are settled the same way.
+func ReconcilePendingOrders(ctx context.Context) (*models.ReconciliationReport, error) {
+	report := models.ReconciliationReport{
+		StartedAt:   time.Now(),
+		Transitions: map[string]int{},
+	}
+
+	var orders []models.Order
+	if err := database.DB.Preload("CreditPackage").
+		Where("status IN ? OR (status = ? AND credits_added = ?)", []string{"pending", "in_process"}, "approved", false).
+		Find(&orders).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch pending orders: %w", err)
+	}
+
+	log.Printf("Found %d orders to reconcile", len(orders))
+
+	expiration := pixExpiration()
+
+	for i := range orders {
+		if closeToDeadline(ctx) {
+			log.Printf("Stopping reconciliation close to deadline, %d of %d orders scanned", report.OrdersScanned, len(orders))
+			break
+		}
+
+		order := &orders[i]
+		report.OrdersScanned++
+		previous := order.Status
+
+		status := ""
+		if paymentID, err := strconv.ParseInt(order.MercadoPagoID, 10, 64); err == nil {
+			status, err = mercadopago.GetPaymentStatus(ctx, int(paymentID))
+			if err != nil {
+				log.Printf("Failed to fetch payment for order %d: %v", order.ID, err)
+				report.Errors++
+				continue
+			}
+		}
+
+		expired := time.Since(order.CreatedAt) > expiration
+		if expired && (status == "" || status == "pending" || status == "in_process") {
+			status = "expired"
+		}
+
+		credited, err := payments.ApplyOrderStatus(order, status)
+		if err != nil {
+			log.Printf("Failed to reconcile order %d: %v", order.ID, err)
+			report.Errors++
+			continue
+		}
+
+		if credited {
+			report.OrdersCredited++
+		}
+
+		if order.Status == previous {
+			report.Unchanged++
+			continue
+		}
+
+		report.Transitions[fmt.Sprintf("%s->%s", previous, order.Status)]++
+	}
+
+	if err := reconcileProcessingInvoices(ctx, &report); err != nil {
+		return nil, err
+	}
+
+	report.FinishedAt = time.Now()
+
+	if err := database.DB.Create(&report).Error; err != nil {
+		return nil, fmt.Errorf("failed to save reconciliation report: %w", err)
+	}
+
+	log.Printf("Reconciliation finished: %d scanned, %d credited, %d invoices scanned, %d paid, %d unchanged, %d errors, transitions: %v",
+		report.OrdersScanned, report.OrdersCredited, report.InvoicesScanned, report.InvoicesPaid, report.Unchanged, report.Errors, report.Transitions)
+
+	return &report, nil
+}
+
+// reconcileProcessingInvoices settles card charges left in process when billing ran and
+// whose webhook never arrived.
+func reconcileProcessingInvoices(ctx context.Context, report *models.ReconciliationReport) error {
+	var invoices []models.Invoice
+	if err := database.DB.Where("status = ?", "processing").Find(&invoices).Error; err != nil {
+		return fmt.Errorf("failed to fetch processing invoices: %w", err)
+	}
+
+	log.Printf("Found %d invoices to reconcile", len(invoices))
+
+	for i := range invoices {
+		if closeToDeadline(ctx) {
+			log.Printf("Stopping invoice reconciliation close to deadline, %d of %d invoices scanned", report.InvoicesScanned, len(invoices))
+			return nil
+		}
+
+		invoice := &invoices[i]
+		report.InvoicesScanned++
+
+		paymentID, err := strconv.ParseInt(invoice.MercadoPagoID, 10, 64)
+		if err != nil {
+			log.Printf("Invoice %d is processing without a payment id", invoice.ID)
+			report.Errors++
+			continue
+		}
+
+		status, err := mercadopago.GetPaymentStatus(ctx, int(paymentID))
+		if err != nil {
+			log.Printf("Failed to fetch payment for invoice %d: %v", invoice.ID, err)
+			report.Errors++
+			continue
+		}
+
+		if err := payments.ApplyInvoiceStatus(invoice, invoice.MercadoPagoID, status); err != nil {
+			log.Printf("Failed to reconcile invoice %d: %v", invoice.ID, err)
+			report.Errors++
+			continue
+		}
+
+		if invoice.Status == "processing" {
+			report.Unchanged++
+			continue
+		}
+
+		if invoice.Status == "paid" {
+			report.InvoicesPaid++
+		}
+		report.Transitions[fmt.Sprintf("invoice processing->%s", invoice.Status)]++
+	}
+
+	return nil
+}
+
+// closeToDeadline reports whether the context is done or about to be.
+func closeToDeadline(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+	deadline, ok := ctx.Deadline()
+	return ok && time.Until(deadline) < deadlineMargin
+}
+
+// pixExpiration reads PIX_EXPIRATION_MINUTES, defaulting to the 24 hours Mercado Pago uses.
+func pixExpiration() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("PIX_EXPIRATION_MINUTES"))
+	if err != nil || minutes <= 0 {
+		return defaultPixExpiration
+	}
+	return time.Duration(minutes) * time.Minute
+}
@@ -0,0 +1,138 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"medina-consultancy-api/pkg/geo"
+	"os"
+	"strconv"
+)
+
+const (
+	defaultGridCellMeters = 2000
+	maxAreaRadiusMeters   = 50000
+)
+
+// Area restricts a search to a circle, a bounding box or a GeoJSON polygon instead of
+// expanding the city name into regions. Exactly one shape must be given.
+type Area struct {
+	Center       *geo.LatLng      `json:"center"`
+	RadiusMeters float64          `json:"radius_meters"`
+	BoundingBox  *geo.BoundingBox `json:"bounding_box"`
+	Polygon      json.RawMessage  `json:"polygon"` // GeoJSON Polygon geometry or Feature
+}
+
+// shape is the parsed form of an Area: its bounding box and an exact containment test.
+type shape struct {
+	bounds   geo.BoundingBox
+	contains func(geo.LatLng) bool
+}
+
+func (a *Area) shape() (*shape, error) {
+	shapes := 0
+	if a.Center != nil {
+		shapes++
+	}
+	if a.BoundingBox != nil {
+		shapes++
+	}
+	if len(a.Polygon) > 0 {
+		shapes++
+	}
+	if shapes != 1 {
+		return nil, fmt.Errorf("area must have exactly one of center, bounding_box or polygon")
+	}
+
+	switch {
+	case a.Center != nil:
+		if a.RadiusMeters <= 0 || a.RadiusMeters > maxAreaRadiusMeters {
+			return nil, fmt.Errorf("radius_meters must be between 1 and %d", maxAreaRadiusMeters)
+		}
+		center, radius := *a.Center, a.RadiusMeters
+		return &shape{
+			bounds:   geo.CircleBounds(center, radius),
+			contains: func(point geo.LatLng) bool { return geo.Distance(center, point) <= radius },
+		}, nil
+
+	case a.BoundingBox != nil:
+		if !a.BoundingBox.Valid() {
+			return nil, fmt.Errorf("bounding_box is invalid")
+		}
+		box := *a.BoundingBox
+		if err := checkAreaSize(box); err != nil {
+			return nil, err
+		}
+		return &shape{bounds: box, contains: box.Contains}, nil
+
+	default:
+		polygon, err := geo.ParseGeoJSONPolygon(a.Polygon)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkAreaSize(polygon.Bounds()); err != nil {
+			return nil, err
+		}
+		return &shape{bounds: polygon.Bounds(), contains: polygon.Contains}, nil
+	}
+}
+
+// checkAreaSize keeps boxes and polygons within the square around the largest circle
+// allowed, so the grid circles stay under the Nearby Search radius limit.
+func checkAreaSize(bounds geo.BoundingBox) error {
+	width, height := bounds.Size()
+	if max(width, height) > 2*maxAreaRadiusMeters {
+		return fmt.Errorf("area must fit in %dkm by %dkm", 2*maxAreaRadiusMeters/1000, 2*maxAreaRadiusMeters/1000)
+	}
+	return nil
+}
+
+// maxGridTiles caps the number of Nearby Search calls per search depth.
+var maxGridTiles = map[string]int{
+	DepthQuick:      4,
+	DepthStandard:   9,
+	DepthExhaustive: 36,
+}
+
+// grid returns the Nearby Search circles covering the area. Cells grow when the area
+// would need more tiles of the configured size than the depth allows.
+func (s *shape) grid(depth string) ([]geo.LatLng, float64) {
+	cellMeters := gridCellMeters()
+	if depth == "" {
+		depth = DepthStandard
+	}
+
+	for {
+		centers := s.bounds.Grid(cellMeters)
+		if len(centers) <= maxGridTiles[depth] {
+			// circles through the corners of the cells Grid made, which are never larger
+			// than cellMeters, so that neighbouring tiles overlap
+			width, height := s.bounds.Size()
+			cols := math.Max(1, math.Ceil(width/cellMeters))
+			rows := math.Max(1, math.Ceil(height/cellMeters))
+			return centers, math.Hypot(width/cols, height/rows) / 2
+		}
+		cellMeters *= 1.5
+	}
+}
+
+func (a *Area) label() string {
+	switch {
+	case a.Center != nil:
+		return fmt.Sprintf("%.5f,%.5f (%.0fm)", a.Center.Lat, a.Center.Lng, a.RadiusMeters)
+	case a.BoundingBox != nil:
+		return fmt.Sprintf("%.5f,%.5f,%.5f,%.5f", a.BoundingBox.South, a.BoundingBox.West, a.BoundingBox.North, a.BoundingBox.East)
+	default:
+		return "polygon"
+	}
+}
+
+// gridCellMeters reads GRID_CELL_METERS, the side of each Nearby Search tile, at most
+// maxAreaRadiusMeters.
+func gridCellMeters() float64 {
+	meters, err := strconv.ParseFloat(os.Getenv("GRID_CELL_METERS"), 64)
+	if err != nil || meters < 100 {
+		return defaultGridCellMeters
+	}
+	return math.Min(meters, maxAreaRadiusMeters)
+}
@@ -0,0 +1,108 @@
+package search
+
+import (
+	"encoding/json"
+	"medina-consultancy-api/pkg/geo"
+	"testing"
+)
+
+func TestAreaShape(t *testing.T) {
+	center := geo.LatLng{Lat: -23.55, Lng: -46.63}
+
+	tests := []struct {
+		name    string
+		area    Area
+		wantErr bool
+	}{
+		{"circle", Area{Center: &center, RadiusMeters: 5000}, false},
+		{"circle too large", Area{Center: &center, RadiusMeters: maxAreaRadiusMeters + 1}, true},
+		{"circle without radius", Area{Center: &center}, true},
+		{"box", Area{BoundingBox: &geo.BoundingBox{South: -23.6, West: -46.7, North: -23.5, East: -46.6}}, false},
+		{"box too large", Area{BoundingBox: &geo.BoundingBox{South: -24, West: -47, North: -22, East: -45}}, true},
+		{"box inverted", Area{BoundingBox: &geo.BoundingBox{South: -23.5, West: -46.7, North: -23.6, East: -46.6}}, true},
+		{"polygon", Area{Polygon: json.RawMessage(`{"type":"Polygon","coordinates":[[[-46.7,-23.6],[-46.6,-23.6],[-46.6,-23.5],[-46.7,-23.6]]]}`)}, false},
+		{"polygon too large", Area{Polygon: json.RawMessage(`{"type":"Polygon","coordinates":[[[-47,-24],[-45,-24],[-45,-22],[-47,-24]]]}`)}, true},
+		{"no shape", Area{}, true},
+		{"two shapes", Area{Center: &center, RadiusMeters: 5000, BoundingBox: &geo.BoundingBox{South: -23.6, West: -46.7, North: -23.5, East: -46.6}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.area.shape(); (err != nil) != tt.wantErr {
+				t.Errorf("shape error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestShapeGrid(t *testing.T) {
+	t.Setenv("GRID_CELL_METERS", "")
+
+	// the largest box accepted, 100km by 100km on the equator
+	largest := geo.BoundingBox{South: -0.449, West: -0.449, North: 0.449, East: 0.449}
+	small := geo.BoundingBox{South: -23.56, West: -46.64, North: -23.55, East: -46.63}
+
+	tests := []struct {
+		name     string
+		box      geo.BoundingBox
+		depth    string
+		maxTiles int
+	}{
+		{"largest box, quick", largest, DepthQuick, 4},
+		{"largest box, standard", largest, DepthStandard, 9},
+		{"largest box, exhaustive", largest, DepthExhaustive, 36},
+		{"largest box, default depth", largest, "", 9},
+		{"small box", small, DepthExhaustive, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			area := Area{BoundingBox: &tt.box}
+			s, err := area.shape()
+			if err != nil {
+				t.Fatalf("shape: %v", err)
+			}
+
+			centers, radius := s.grid(tt.depth)
+			if len(centers) == 0 || len(centers) > tt.maxTiles {
+				t.Errorf("grid = %d tiles, want 1 to %d", len(centers), tt.maxTiles)
+			}
+			if radius > maxAreaRadiusMeters {
+				t.Errorf("radius = %.0fm, above the %dm limit", radius, maxAreaRadiusMeters)
+			}
+
+			// every point of the box is inside some circle
+			for lat := tt.box.South; lat <= tt.box.North; lat += (tt.box.North - tt.box.South) / 10 {
+				for lng := tt.box.West; lng <= tt.box.East; lng += (tt.box.East - tt.box.West) / 10 {
+					if !covered(geo.LatLng{Lat: lat, Lng: lng}, centers, radius) {
+						t.Fatalf("point %.4f,%.4f is not covered by the grid", lat, lng)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestShapeGridLargeCell(t *testing.T) {
+	t.Setenv("GRID_CELL_METERS", "500000")
+
+	area := Area{BoundingBox: &geo.BoundingBox{South: -0.449, West: -0.449, North: 0.449, East: 0.449}}
+	s, err := area.shape()
+	if err != nil {
+		t.Fatalf("shape: %v", err)
+	}
+
+	if _, radius := s.grid(DepthQuick); radius > maxAreaRadiusMeters {
+		t.Errorf("radius = %.0fm, above the %dm limit", radius, maxAreaRadiusMeters)
+	}
+}
+
+func covered(point geo.LatLng, centers []geo.LatLng, radius float64) bool {
+	for _, center := range centers {
+		// a meter of slack for the flat cell math
+		if geo.Distance(center, point) <= radius+1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package search
+
+import (
+	"fmt"
+	"medina-consultancy-api/database"
+	"medina-consultancy-api/models"
+	"medina-consultancy-api/pkg/places"
+
+	"gorm.io/gorm"
+)
+
+// BatchItem is one search of a new batch.
+type BatchItem struct {
+	SearchID string             `json:"search_id"`
+	Request  CityRequest        `json:"request"`
+	Credits  int                `json:"credits_used"` // 0 for integration batches, billed per query
+	Quote    models.CreditQuote `json:"-"`
+}
+
+// CreateBatch stores the batch with a queued job for each item, run by the search worker
+// like any async search. Credits of the items must already be reserved.
+func CreateBatch(batch *models.SearchBatch, items []BatchItem) error {
+	jobs := make([]models.SearchJob, len(items))
+	for i, item := range items {
+		job, err := newJob(batch.UserID, item.SearchID, item.Request, item.Quote)
+		if err != nil {
+			return err
+		}
+		job.SubscriptionID = batch.SubscriptionID
+		job.BatchID = batch.BatchID
+		jobs[i] = job
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(batch).Error; err != nil {
+			return fmt.Errorf("failed to create search batch: %w", err)
+		}
+		if err := tx.CreateInBatches(&jobs, 100).Error; err != nil {
+			return fmt.Errorf("failed to create search jobs: %w", err)
+		}
+		return nil
+	})
+}
+
+// BatchStatus sums up the jobs of a batch: queued until one starts, running until all
+// finish, then done, failed or partial when only some failed.
+func BatchStatus(jobs []models.SearchJob) string {
+	counts := make(map[string]int)
+	for _, job := range jobs {
+		counts[job.Status]++
+	}
+
+	switch {
+	case counts["queued"] == len(jobs):
+		return "queued"
+	case counts["queued"] > 0 || counts["running"] > 0:
+		return "running"
+	case counts["failed"] == 0:
+		return "done"
+	case counts["done"] == 0:
+		return "failed"
+	default:
+		return "partial"
+	}
+}
+
+// BatchResults merges the results of the given searches in order, keeping the first
+// occurrence of places found by more than one of them.
+func BatchResults(searchIDs []string) ([]places.PlaceDetails, error) {
+	var merged []places.PlaceDetails
+	seen := make(map[string]bool)
+
+	for _, searchID := range searchIDs {
+		results, _, err := LoadResults(searchID)
+		if err != nil {
+			return nil, err
+		}
+		for _, place := range results {
+			key := placeKey(place.PlaceID, place.Name, place.FormattedAddress)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged = append(merged, place)
+		}
+	}
+
+	return merged, nil
+}
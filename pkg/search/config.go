@@ -0,0 +1,60 @@
+package search
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultReuseWindow   = 7 * 24 * time.Hour
+	defaultMaxBatchItems = 50
+
+	// API Gateway gives up on the request after 29 seconds
+	defaultSyncTimeout = 25 * time.Second
+)
+
+// RefundEmptySearches reports whether searches that find no places get their credits back
+// (REFUND_EMPTY_SEARCHES=true).
+func RefundEmptySearches() bool {
+	return os.Getenv("REFUND_EMPTY_SEARCHES") == "true"
+}
+
+// ReuseWindow is how old an identical search can be and still be re-used
+// (SEARCH_REUSE_WINDOW, a Go duration, 0 disables re-use).
+func ReuseWindow() time.Duration {
+	if window, err := time.ParseDuration(os.Getenv("SEARCH_REUSE_WINDOW")); err == nil {
+		return window
+	}
+	return defaultReuseWindow
+}
+
+// ReuseCredits is what a re-used search costs (SEARCH_REUSE_CREDITS, free by default).
+func ReuseCredits() int {
+	if credits, err := strconv.Atoi(os.Getenv("SEARCH_REUSE_CREDITS")); err == nil && credits >= 0 {
+		return credits
+	}
+	return 0
+}
+
+// SyncTimeout bounds searches run inside the HTTP request (SEARCH_SYNC_TIMEOUT).
+func SyncTimeout() time.Duration {
+	if timeout, err := time.ParseDuration(os.Getenv("SEARCH_SYNC_TIMEOUT")); err == nil && timeout > 0 {
+		return timeout
+	}
+	return defaultSyncTimeout
+}
+
+// RefundPartialSearches reports whether the credits of queries that failed on the provider
+// are refunded (REFUND_PARTIAL_SEARCHES=true).
+func RefundPartialSearches() bool {
+	return os.Getenv("REFUND_PARTIAL_SEARCHES") == "true"
+}
+
+// MaxBatchItems is how many searches a batch can hold (BATCH_MAX_ITEMS).
+func MaxBatchItems() int {
+	if items, err := strconv.Atoi(os.Getenv("BATCH_MAX_ITEMS")); err == nil && items > 0 {
+		return items
+	}
+	return defaultMaxBatchItems
+}
@@ -0,0 +1,440 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"medina-consultancy-api/models"
+	"medina-consultancy-api/pkg/enrich"
+	"medina-consultancy-api/pkg/geo"
+	"medina-consultancy-api/pkg/phone"
+	"medina-consultancy-api/pkg/places"
+	"strings"
+	"sync"
+)
+
+const (
+	maxPages = 3
+
+	// goroutines of a single search; the outbound client bounds the requests of all
+	// searches together
+	maxConcurrentQueries  = 4
+	maxConcurrentDetails  = 8
+	maxConcurrentWebsites = 8
+)
+
+// ProgressFunc is called every time a region finishes with the number of regions done
+// and the number of unique places found so far.
+type ProgressFunc func(regionsDone int, placesFound int)
+
+// Meta summarizes how a search went and is returned as the response meta.
+type Meta struct {
+	FilteredOut int `json:"filtered_out"`
+	OutsideArea int `json:"outside_area,omitempty"`
+	GridTiles   int `json:"grid_tiles,omitempty"`
+
+	Cache *places.CacheStats `json:"cache,omitempty"`
+
+	// provider failures; the search still succeeds with what was found
+	QueriesTotal   int                    `json:"queries_total"`
+	QueriesFailed  int                    `json:"queries_failed,omitempty"`
+	DetailsMissing int                    `json:"details_missing,omitempty"`
+	Warnings       []models.SearchWarning `json:"warnings,omitempty"`
+
+	// places whose website was visited for contacts
+	Enriched int `json:"enriched,omitempty"`
+
+	// places left out by only_new, set by ExcludeSeen
+	ExcludedSeen int `json:"excluded_seen,omitempty"`
+}
+
+// PartialRefund is the share of cost matching the queries that failed, refunded when
+// REFUND_PARTIAL_SEARCHES is enabled.
+func (m Meta) PartialRefund(cost int) int {
+	if !RefundPartialSearches() || m.QueriesTotal == 0 {
+		return 0
+	}
+	return cost * m.QueriesFailed / m.QueriesTotal
+}
+
+type Result struct {
+	Places []places.PlaceDetails
+	Meta   Meta
+}
+
+// query is one unit of work of a search: a text search for a city region or a nearby
+// search for one tile of an area grid.
+type query struct {
+	label  string
+	text   *places.SearchRequest
+	nearby *places.NearbyRequest
+}
+
+// run holds the state shared by the query goroutines of a single search.
+type run struct {
+	ctx      context.Context
+	provider places.PlaceProvider
+	request  CityRequest
+	area     *shape
+
+	mutex          sync.Mutex
+	uniquePlaces   map[string]places.PlaceDetails
+	filtered       map[string]bool
+	outside        map[string]bool
+	warnings       []models.SearchWarning
+	failedQueries  int
+	detailsFailed  map[string]int // by provider status
+	detailsMessage map[string]string
+	enrichFailed   map[string]int // by enrichment status
+	enrichMessage  map[string]string
+	enriched       int
+}
+
+// plan expands the request into the queries sent to the provider: one text search per
+// city region, or one nearby search per grid tile when an area is given.
+func plan(cityReq CityRequest) ([]query, error) {
+	searchQuery := cityReq.Search
+	if len(cityReq.Keywords) > 0 {
+		searchQuery = fmt.Sprintf("%s %s", searchQuery, strings.Join(cityReq.Keywords, " "))
+	}
+
+	var queries []query
+
+	if cityReq.Area != nil {
+		area, err := cityReq.Area.shape()
+		if err != nil {
+			return nil, err
+		}
+
+		centers, radius := area.grid(cityReq.Depth)
+		for i, center := range centers {
+			queries = append(queries, query{
+				label: fmt.Sprintf("tile %d/%d", i+1, len(centers)),
+				nearby: &places.NearbyRequest{
+					Keyword:      searchQuery,
+					Location:     center,
+					RadiusMeters: radius,
+					PlaceType:    cityReq.PlaceType,
+				},
+			})
+		}
+
+		return queries, nil
+	}
+
+	regions, err := ExpandRegions(cityReq.City, cityReq.Depth)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, region := range regions {
+		cityQuery := cityReq.City
+		if region != "" {
+			cityQuery = fmt.Sprintf("%s %s", cityReq.City, region)
+		}
+
+		queries = append(queries, query{
+			label: cityQuery,
+			text: &places.SearchRequest{
+				Query:     searchQuery,
+				Location:  cityQuery,
+				PlaceType: cityReq.PlaceType,
+			},
+		})
+	}
+
+	return queries, nil
+}
+
+// Run executes every query of the request concurrently and returns the unique places
+// that pass the request filters and lie inside the requested area.
+func Run(ctx context.Context, provider places.PlaceProvider, cityReq CityRequest, onProgress ProgressFunc) (*Result, error) {
+	queries, err := plan(cityReq)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &run{
+		ctx:          ctx,
+		provider:     provider,
+		request:      cityReq,
+		uniquePlaces: make(map[string]places.PlaceDetails),
+		filtered:     make(map[string]bool),
+		outside:      make(map[string]bool),
+
+		detailsFailed:  make(map[string]int),
+		detailsMessage: make(map[string]string),
+		enrichFailed:   make(map[string]int),
+		enrichMessage:  make(map[string]string),
+	}
+
+	if cityReq.Area != nil {
+		if r.area, err = cityReq.Area.shape(); err != nil {
+			return nil, err
+		}
+	}
+
+	pending := make(chan query, len(queries))
+	for _, q := range queries {
+		pending <- q
+	}
+	close(pending)
+
+	var wg sync.WaitGroup
+	queriesDone := 0
+
+	for worker := 0; worker < min(maxConcurrentQueries, len(queries)); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for q := range pending {
+				if ctx.Err() != nil {
+					return
+				}
+
+				r.fetchPlacesForQuery(q)
+
+				if onProgress != nil {
+					r.mutex.Lock()
+					queriesDone++
+					done, found := queriesDone, len(r.uniquePlaces)
+					r.mutex.Unlock()
+					onProgress(done, found)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// the caller went away or the deadline passed, the results are incomplete
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if r.failedQueries == len(queries) {
+		return nil, fmt.Errorf("all %d queries failed on %s: %s", len(queries), provider.Name(), r.warnings[0].Message)
+	}
+
+	r.classifyPhones()
+
+	if cityReq.Enrich {
+		r.enrichPlaces(enrich.Default())
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &Result{Meta: Meta{
+		FilteredOut:   len(r.filtered),
+		OutsideArea:   len(r.outside),
+		QueriesTotal:  len(queries),
+		QueriesFailed: r.failedQueries,
+		Warnings:      r.warnings,
+	}}
+	for status, count := range r.detailsFailed {
+		result.Meta.DetailsMissing += count
+		result.Meta.Warnings = append(result.Meta.Warnings, models.SearchWarning{
+			Stage:   "details",
+			Status:  status,
+			Message: r.detailsMessage[status],
+			Count:   count,
+		})
+	}
+	for status, count := range r.enrichFailed {
+		result.Meta.Warnings = append(result.Meta.Warnings, models.SearchWarning{
+			Stage:   "enrichment",
+			Status:  status,
+			Message: r.enrichMessage[status],
+			Count:   count,
+		})
+	}
+	result.Meta.Enriched = r.enriched
+	if r.area != nil {
+		result.Meta.GridTiles = len(queries)
+	}
+	if cached, ok := provider.(*places.CachingProvider); ok {
+		stats := cached.Stats()
+		result.Meta.Cache = &stats
+	}
+
+	for _, place := range r.uniquePlaces {
+		result.Places = append(result.Places, place)
+	}
+
+	return result, nil
+}
+
+func (r *run) fetchPlacesForQuery(q query) {
+	nextPageToken := ""
+
+	for pageCount := 0; pageCount < maxPages; pageCount++ {
+		var page *places.SearchPage
+		var err error
+
+		if q.nearby != nil {
+			req := *q.nearby
+			req.PageToken = nextPageToken
+			page, err = r.provider.NearbySearch(r.ctx, req)
+		} else {
+			req := *q.text
+			req.PageToken = nextPageToken
+			page, err = r.provider.TextSearch(r.ctx, req)
+		}
+
+		if err != nil {
+			log.Printf("Failed to search places on %s: %v", r.provider.Name(), err)
+			r.mutex.Lock()
+			r.failedQueries++
+			r.warnings = append(r.warnings, models.SearchWarning{
+				Stage:   "search",
+				Query:   q.label,
+				Page:    pageCount + 1,
+				Status:  places.ErrorStatus(err),
+				Message: places.ErrorMessage(err),
+			})
+			r.mutex.Unlock()
+			break
+		}
+
+		log.Printf("Found %d results for query: %s", len(page.Results), q.label)
+
+		var detailsWg sync.WaitGroup
+		detailsSlots := make(chan struct{}, maxConcurrentDetails)
+
+		for _, result := range page.Results {
+			// without details the phone, website and hours stay empty, SaveResults keeps
+			// the ones stored for the place by earlier searches
+			if !r.add(result) || r.request.SkipDetails {
+				continue
+			}
+
+			detailsWg.Add(1)
+			detailsSlots <- struct{}{}
+			go func(placeID string) {
+				defer func() {
+					<-detailsSlots
+					detailsWg.Done()
+				}()
+				r.fetchDetails(placeID)
+			}(result.PlaceID)
+		}
+
+		detailsWg.Wait()
+
+		if page.NextPageToken == "" {
+			break
+		}
+
+		nextPageToken = page.NextPageToken
+	}
+}
+
+// add records a search result and reports whether it is new and passed the filters,
+// meaning its details still have to be fetched.
+func (r *run) add(place places.PlaceDetails) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.uniquePlaces[place.PlaceID]; exists || r.filtered[place.PlaceID] || r.outside[place.PlaceID] {
+		return false
+	}
+
+	// nearby tiles overlap the area edges, clip what falls outside of it
+	if r.area != nil && !r.area.contains(geo.LatLng{Lat: place.Lat, Lng: place.Lng}) {
+		r.outside[place.PlaceID] = true
+		return false
+	}
+
+	if !r.request.Accepts(place) {
+		r.filtered[place.PlaceID] = true
+		return false
+	}
+
+	r.uniquePlaces[place.PlaceID] = place
+	return true
+}
+
+func (r *run) fetchDetails(placeID string) {
+	details, err := r.provider.Details(r.ctx, placeID)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err != nil {
+		log.Printf("Failed to fetch details for place %s: %v", placeID, err)
+		status := places.ErrorStatus(err)
+		r.detailsFailed[status]++
+		r.detailsMessage[status] = places.ErrorMessage(err)
+		return
+	}
+
+	if place, exists := r.uniquePlaces[placeID]; exists {
+		place.FormattedPhoneNumber = details.FormattedPhoneNumber
+		place.Website = details.Website
+		if details.OpeningHours != nil {
+			place.OpeningHours = details.OpeningHours
+		}
+		r.uniquePlaces[placeID] = place
+	}
+}
+
+// classifyPhones normalizes the phones found and applies only_mobile, which can only be
+// checked once the details are known.
+func (r *run) classifyPhones() {
+	for placeID, place := range r.uniquePlaces {
+		ClassifyPhone(&place, r.request.PhoneCountry())
+
+		if r.request.OnlyMobile && place.PhoneType != phone.TypeMobile {
+			delete(r.uniquePlaces, placeID)
+			r.filtered[placeID] = true
+			continue
+		}
+
+		r.uniquePlaces[placeID] = place
+	}
+}
+
+// enrichPlaces visits the website of every place found for emails, phones and social
+// links. Places whose website fails keep what the provider returned.
+func (r *run) enrichPlaces(enricher *enrich.Enricher) {
+	var pending []places.PlaceDetails
+	for _, place := range r.uniquePlaces {
+		if place.Website != "" {
+			pending = append(pending, place)
+		}
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, maxConcurrentWebsites)
+
+	for i := range pending {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(place *places.PlaceDetails) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			err := enricher.Enrich(r.ctx, place)
+
+			r.mutex.Lock()
+			defer r.mutex.Unlock()
+
+			if err != nil {
+				log.Printf("Failed to enrich place %s from %s: %v", place.PlaceID, place.Website, err)
+				status := enrich.ErrorStatus(err)
+				r.enrichFailed[status]++
+				r.enrichMessage[status] = err.Error()
+				return
+			}
+
+			r.enriched++
+			r.uniquePlaces[place.PlaceID] = *place
+		}(&pending[i])
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,105 @@
+package search
+
+import (
+	"context"
+	"medina-consultancy-api/pkg/geo"
+	"medina-consultancy-api/pkg/places"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeProvider returns its places from every search without contacts, like Google does,
+// and the full place from Details.
+type fakeProvider struct {
+	places  []places.PlaceDetails
+	details atomic.Int32
+}
+
+func (p *fakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *fakeProvider) TextSearch(ctx context.Context, req places.SearchRequest) (*places.SearchPage, error) {
+	return p.page(), nil
+}
+
+func (p *fakeProvider) NearbySearch(ctx context.Context, req places.NearbyRequest) (*places.SearchPage, error) {
+	return p.page(), nil
+}
+
+func (p *fakeProvider) page() *places.SearchPage {
+	page := &places.SearchPage{}
+	for _, place := range p.places {
+		place.FormattedPhoneNumber = ""
+		place.Website = ""
+		place.OpeningHours = nil
+		page.Results = append(page.Results, place)
+	}
+	return page
+}
+
+func (p *fakeProvider) Details(ctx context.Context, placeID string) (*places.PlaceDetails, error) {
+	p.details.Add(1)
+	for _, place := range p.places {
+		if place.PlaceID == placeID {
+			return &place, nil
+		}
+	}
+	return nil, &places.ProviderError{Provider: "fake", Status: "NOT_FOUND"}
+}
+
+func areaRequest() CityRequest {
+	center := geo.LatLng{Lat: -23.55, Lng: -46.63}
+	return CityRequest{
+		Search: "padaria",
+		Area:   &Area{Center: &center, RadiusMeters: 1000},
+		Depth:  DepthQuick,
+	}
+}
+
+func TestRunSkipDetails(t *testing.T) {
+	provider := &fakeProvider{places: []places.PlaceDetails{{
+		PlaceID:              "abc",
+		Name:                 "Padaria",
+		Lat:                  -23.55,
+		Lng:                  -46.63,
+		FormattedPhoneNumber: "(11) 91234-5678",
+		Website:              "https://padaria.com.br",
+		OpeningHours:         &places.OpeningHours{OpenNow: true, WeekdayText: []string{"segunda-feira: 07:00–20:00"}},
+	}}}
+
+	cityReq := areaRequest()
+	cityReq.SkipDetails = true
+
+	result, err := Run(context.Background(), provider, cityReq, nil)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if calls := provider.details.Load(); calls != 0 {
+		t.Errorf("Details called %d time(s), want none", calls)
+	}
+	if len(result.Places) != 1 {
+		t.Fatalf("Run = %d places, want 1", len(result.Places))
+	}
+
+	// the row saved is empty where Details would have filled it, SaveResults keeps what
+	// an earlier search stored in those columns (TestSaveResultsKeepsDetails)
+	row := placeRow(provider.Name(), result.Places[0])
+	if row.FormattedPhoneNumber != "" || row.Website != "" || row.PhoneE164 != "" || row.WeekdayText != nil {
+		t.Errorf("row = %q/%q/%q/%v, want no phone, website or hours", row.FormattedPhoneNumber, row.Website, row.PhoneE164, row.WeekdayText)
+	}
+
+	result, err = Run(context.Background(), provider, areaRequest(), nil)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if calls := provider.details.Load(); calls != 1 {
+		t.Errorf("Details called %d time(s), want 1", calls)
+	}
+	if place := result.Places[0]; place.FormattedPhoneNumber == "" || place.Website == "" || place.PhoneE164 != "+5511912345678" {
+		t.Errorf("place = %q/%q/%q, want the details", place.FormattedPhoneNumber, place.Website, place.PhoneE164)
+	}
+	if hours := result.Places[0].OpeningHours; hours == nil || !hours.OpenNow || len(hours.WeekdayText) != 1 {
+		t.Errorf("OpeningHours = %+v, want the hours from Details", hours)
+	}
+}
@@ -0,0 +1,91 @@
+package search
+
+import (
+	"fmt"
+	"medina-consultancy-api/database"
+	"medina-consultancy-api/pkg/textutil"
+	"time"
+)
+
+const (
+	// recent searches averaged by an estimate
+	estimateSamples = 20
+
+	// searches in the same place compared with the query of a similar estimate
+	similarCandidates = 200
+)
+
+// ResultEstimate is how many places a search will likely find, judged from earlier searches.
+type ResultEstimate struct {
+	Results int    `json:"approximate_results"`
+	Basis   string `json:"basis"` // same_search, similar_searches or none
+	Samples int    `json:"samples"`
+}
+
+type pastSearch struct {
+	Query     string
+	Results   int
+	CreatedAt time.Time
+}
+
+// EstimateResults averages the results of earlier searches of any user: identical ones
+// when there are, otherwise the same query in the same place with other options. It only
+// reads the search history, the provider is never called.
+func EstimateResults(cityReq CityRequest) (ResultEstimate, error) {
+	identical, err := pastSearches("fingerprint = ?", estimateSamples, cityReq.Fingerprint())
+	if err != nil {
+		return ResultEstimate{}, err
+	}
+	if len(identical) > 0 {
+		return averageResults(identical, "same_search"), nil
+	}
+
+	candidates, err := pastSearches("LOWER(city) = LOWER(?)", similarCandidates, cityReq.Location())
+	if err != nil {
+		return ResultEstimate{}, err
+	}
+
+	query := textutil.Fold(cityReq.Search)
+	var similar []pastSearch
+	for _, candidate := range candidates {
+		if textutil.Fold(candidate.Query) == query {
+			similar = append(similar, candidate)
+		}
+		if len(similar) == estimateSamples {
+			break
+		}
+	}
+	if len(similar) > 0 {
+		return averageResults(similar, "similar_searches"), nil
+	}
+
+	return ResultEstimate{Basis: "none"}, nil
+}
+
+// pastSearches loads the latest searches and integration queries matching condition,
+// leaving out re-uses which repeat the results of another search.
+func pastSearches(condition string, limit int, value interface{}) ([]pastSearch, error) {
+	var rows []pastSearch
+	err := database.DB.Raw(`SELECT query, results, created_at FROM searches
+			WHERE deleted_at IS NULL AND COALESCE(reused_from, '') = '' AND `+condition+`
+		UNION ALL
+		SELECT query, results, created_at FROM integration_queries
+			WHERE deleted_at IS NULL AND reused = false AND `+condition+`
+		ORDER BY created_at DESC LIMIT ?`, value, value, limit).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to read search history: %w", err)
+	}
+	return rows, nil
+}
+
+func averageResults(searches []pastSearch, basis string) ResultEstimate {
+	total := 0
+	for _, search := range searches {
+		total += search.Results
+	}
+	return ResultEstimate{
+		Results: (total + len(searches)/2) / len(searches),
+		Basis:   basis,
+		Samples: len(searches),
+	}
+}
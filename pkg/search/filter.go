@@ -0,0 +1,53 @@
+package search
+
+import (
+	"medina-consultancy-api/pkg/phone"
+	"medina-consultancy-api/pkg/places"
+)
+
+// Accepts applies the request filters to a search result. Places with an unknown
+// price level are kept, places without ratings fail min_rating and min_reviews.
+func (r CityRequest) Accepts(place places.PlaceDetails) bool {
+	if r.MinRating > 0 && place.Rating < r.MinRating {
+		return false
+	}
+
+	if r.MinReviews > 0 && place.UserRatingsTotal < r.MinReviews {
+		return false
+	}
+
+	if r.PriceLevel > 0 && place.PriceLevel > r.PriceLevel {
+		return false
+	}
+
+	if r.ExcludeClosed && (place.BusinessStatus == "CLOSED_TEMPORARILY" || place.BusinessStatus == "CLOSED_PERMANENTLY") {
+		return false
+	}
+
+	return true
+}
+
+// ClassifyPhone fills the normalized phone fields of a place, reading numbers without a
+// country code as numbers of the given country.
+func ClassifyPhone(place *places.PlaceDetails, country string) {
+	if place.FormattedPhoneNumber == "" {
+		place.PhoneE164, place.PhoneType, place.WhatsAppLikely = "", "", false
+		return
+	}
+
+	info := phone.Classify(place.FormattedPhoneNumber, country)
+	place.PhoneE164 = info.E164
+	place.PhoneType = info.Type
+	place.WhatsAppLikely = info.WhatsApp
+}
+
+// OnlyMobile keeps the places whose phone is a mobile number.
+func OnlyMobile(results []places.PlaceDetails) []places.PlaceDetails {
+	mobile := make([]places.PlaceDetails, 0, len(results))
+	for _, place := range results {
+		if place.PhoneType == phone.TypeMobile {
+			mobile = append(mobile, place)
+		}
+	}
+	return mobile
+}
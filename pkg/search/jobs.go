@@ -0,0 +1,302 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"medina-consultancy-api/database"
+	"medina-consultancy-api/models"
+	"medina-consultancy-api/pkg/credits"
+	"medina-consultancy-api/pkg/places"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	maxJobAttempts = 3
+
+	// a running job not finished after this long is assumed to belong to a dead worker;
+	// kept above the 900s timeout of the worker functions so a live job is never re-run
+	staleJobTimeout = 20 * time.Minute
+
+	// stop claiming new jobs when the invocation deadline is this close
+	jobDeadlineMargin = 2 * time.Minute
+
+	// synchronous searches are bounded by the API timeout, anything older was interrupted
+	staleReservationAge = 10 * time.Minute
+)
+
+// EnqueueJob stores a queued search job for the given user. The Reserved credits of the
+// quote must already be debited.
+func EnqueueJob(userID uint, searchID string, cityReq CityRequest, quote models.CreditQuote) (*models.SearchJob, error) {
+	job, err := newJob(userID, searchID, cityReq, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := database.DB.Create(&job).Error; err != nil {
+		return nil, fmt.Errorf("failed to create search job: %w", err)
+	}
+
+	return &job, nil
+}
+
+func newJob(userID uint, searchID string, cityReq CityRequest, quote models.CreditQuote) (models.SearchJob, error) {
+	request, err := json.Marshal(cityReq)
+	if err != nil {
+		return models.SearchJob{}, fmt.Errorf("failed to encode search request: %w", err)
+	}
+
+	queries, err := plan(cityReq)
+	if err != nil {
+		return models.SearchJob{}, err
+	}
+
+	return models.SearchJob{
+		UserID:       userID,
+		SearchID:     searchID,
+		Status:       "queued",
+		Request:      string(request),
+		CreditsUsed:  quote.Reserved(),
+		Quote:        quote,
+		RegionsTotal: len(queries),
+	}, nil
+}
+
+// ProcessSearchJobs drains the queue, running one job at a time until there is nothing
+// left to do or the context deadline gets close.
+func ProcessSearchJobs(ctx context.Context) error {
+	requeueStaleJobs()
+	failStaleSavedRuns()
+
+	if released, err := credits.ReleaseStaleReservations(staleReservationAge); err != nil {
+		log.Printf("Failed to release stale reservations: %v", err)
+	} else if released > 0 {
+		log.Printf("Released %d stale credit reservation(s)", released)
+	}
+
+	if purged, err := places.PurgeExpiredCache(); err != nil {
+		log.Printf("Failed to purge expired place cache: %v", err)
+	} else if purged > 0 {
+		log.Printf("Purged %d expired place cache entries", purged)
+	}
+
+	processed := 0
+	for {
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < jobDeadlineMargin {
+			log.Printf("Stopping search worker close to deadline, %d job(s) processed", processed)
+			return nil
+		}
+
+		job, err := claimNextJob()
+		if err != nil {
+			return err
+		}
+		if job == nil {
+			log.Printf("Search queue is empty, %d job(s) processed", processed)
+			return nil
+		}
+
+		if err := runJob(ctx, job); err != nil {
+			log.Printf("Search job %s failed: %v", job.SearchID, err)
+		}
+		processed++
+	}
+}
+
+func claimNextJob() (*models.SearchJob, error) {
+	for {
+		var job models.SearchJob
+		err := database.DB.Where("status = ?", "queued").Order("created_at ASC").Limit(1).Find(&job).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch queued jobs: %w", err)
+		}
+		if job.ID == 0 {
+			return nil, nil
+		}
+
+		now := time.Now()
+		result := database.DB.Model(&models.SearchJob{}).
+			Where("id = ? AND status = ?", job.ID, "queued").
+			Updates(map[string]interface{}{
+				"status":     "running",
+				"started_at": now,
+				"attempts":   job.Attempts + 1,
+			})
+		if result.Error != nil {
+			return nil, fmt.Errorf("failed to claim job %d: %w", job.ID, result.Error)
+		}
+
+		// another worker claimed it first
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		job.Status = "running"
+		job.StartedAt = &now
+		job.Attempts++
+		return &job, nil
+	}
+}
+
+func requeueStaleJobs() {
+	cutoff := time.Now().Add(-staleJobTimeout)
+
+	database.DB.Model(&models.SearchJob{}).
+		Where("status = ? AND started_at < ? AND attempts < ?", "running", cutoff, maxJobAttempts).
+		Updates(map[string]interface{}{"status": "queued", "regions_done": 0, "partial_results": 0})
+
+	var timedOut []models.SearchJob
+	database.DB.Where("status = ? AND started_at < ? AND attempts >= ?", "running", cutoff, maxJobAttempts).Find(&timedOut)
+	for i := range timedOut {
+		failJob(&timedOut[i], fmt.Errorf("job timed out"))
+	}
+}
+
+func runJob(ctx context.Context, job *models.SearchJob) error {
+	var cityReq CityRequest
+	if err := json.Unmarshal([]byte(job.Request), &cityReq); err != nil {
+		return failJob(job, fmt.Errorf("invalid search request: %w", err))
+	}
+
+	provider, err := places.NewProviderFromEnv()
+	if err != nil {
+		return failJob(job, fmt.Errorf("failed to configure place provider: %w", err))
+	}
+
+	result, err := Run(ctx, provider, cityReq, func(regionsDone int, placesFound int) {
+		database.DB.Model(&models.SearchJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+			"regions_done":    regionsDone,
+			"partial_results": placesFound,
+		})
+	})
+	if err != nil {
+		return failJob(job, err)
+	}
+	owner := Owner{UserID: job.UserID}
+	if job.SubscriptionID != nil {
+		owner.SubscriptionID = *job.SubscriptionID
+	}
+	if cityReq.OnlyNew {
+		if err := ExcludeSeen(result, provider.Name(), owner); err != nil {
+			return failJob(job, err)
+		}
+	}
+	results := result.Places
+
+	log.Printf("Search job %s - Total unique results: %d", job.SearchID, len(results))
+
+	fileName, bucketURL, err := UploadCSV(job.SearchID, results)
+	if err != nil {
+		return failJob(job, err)
+	}
+
+	// integration jobs are billed per query instead of credits
+	if job.SubscriptionID != nil {
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(IntegrationQueryRecord(*job.SubscriptionID, job.UserID, job.SearchID, cityReq, len(results), bucketURL)).Error; err != nil {
+				return fmt.Errorf("failed to save integration query record: %w", err)
+			}
+			if err := SaveResults(tx, provider.Name(), job.SearchID, results); err != nil {
+				return err
+			}
+			return MarkSeen(tx, owner, job.SearchID)
+		})
+		if err != nil {
+			return failJob(job, err)
+		}
+	} else {
+		// jobs queued before the pricing was stored with them
+		quote := job.Quote
+		if quote.Reserved() != job.CreditsUsed {
+			quote = models.CreditQuote{Search: job.CreditsUsed}
+		}
+		refund := SearchRefund(result, quote)
+
+		searchRecord := models.Search{
+			UserID:          job.UserID,
+			SearchID:        job.SearchID,
+			Query:           cityReq.Search,
+			City:            cityReq.Location(),
+			BucketURL:       bucketURL,
+			FileName:        fileName,
+			Results:         len(results),
+			Fingerprint:     cityReq.Fingerprint(),
+			Warnings:        result.Meta.Warnings,
+			DetailsMissing:  result.Meta.DetailsMissing,
+			CreditsRefunded: refund,
+			ExcludedSeen:    result.Meta.ExcludedSeen,
+		}
+
+		if err := SaveSearch(&searchRecord, provider.Name(), results); err != nil {
+			return failJob(job, err)
+		}
+
+		SettleSearchCredits("search", job.SearchID, job.CreditsUsed, refund)
+	}
+
+	now := time.Now()
+	return database.DB.Model(job).Updates(map[string]interface{}{
+		"status":        "done",
+		"results":       len(results),
+		"filtered_out":  result.Meta.FilteredOut,
+		"excluded_seen": result.Meta.ExcludedSeen,
+		"finished_at":   now,
+	}).Error
+}
+
+func failJob(job *models.SearchJob, cause error) error {
+	database.DB.Model(job).Updates(map[string]interface{}{
+		"status":      "failed",
+		"error":       cause.Error(),
+		"finished_at": time.Now(),
+	})
+
+	if job.SubscriptionID == nil {
+		if _, err := credits.ReleaseReservation("search", job.SearchID, cause.Error()); err != nil {
+			log.Printf("Failed to refund credits for search %s: %v", job.SearchID, err)
+		}
+	}
+
+	return cause
+}
+
+// SearchRefund is the part of the reserved credits given back once the search ran: all of
+// them when it found nothing, the result charge not used and the share of the base whose
+// queries failed on the provider.
+func SearchRefund(result *Result, quote models.CreditQuote) int {
+	if len(result.Places) == 0 && RefundEmptySearches() {
+		return quote.Reserved()
+	}
+	unused := quote.ResultCap - quote.ResultCharge(len(result.Places))
+	return unused + result.Meta.PartialRefund(quote.Base())
+}
+
+// SettleSearchCredits commits the credits reserved for a search, giving refund of them
+// back. It returns the credits used and the user's balance after the refund, -1 when no
+// refund was made. A failed refund is logged and leaves the whole cost used.
+func SettleSearchCredits(referenceType string, searchID string, cost int, refund int) (used int, balance int) {
+	switch {
+	case refund == cost:
+		balance, err := credits.ReleaseReservation(referenceType, searchID, "Search returned no results")
+		if err != nil {
+			log.Printf("Failed to refund empty search %s: %v", searchID, err)
+			return cost, -1
+		}
+		return 0, balance
+	case refund > 0:
+		balance, err := credits.CommitPartial(referenceType, searchID, cost-refund, "Partial search coverage")
+		if err != nil {
+			log.Printf("Failed to refund partial search %s: %v", searchID, err)
+			return cost, -1
+		}
+		return cost - refund, balance
+	default:
+		if err := credits.CommitReservation(referenceType, searchID); err != nil {
+			log.Printf("Failed to commit credit reservation for search %s: %v", searchID, err)
+		}
+		return cost, -1
+	}
+}
@@ -0,0 +1,84 @@
+package search
+
+import (
+	"fmt"
+	"medina-consultancy-api/database"
+	"medina-consultancy-api/models"
+	"medina-consultancy-api/pkg/pricing"
+	"medina-consultancy-api/pkg/textutil"
+)
+
+const (
+	DepthQuick      = "quick"
+	DepthStandard   = "standard"
+	DepthExhaustive = "exhaustive"
+
+	standardRegionLimit   = 5
+	exhaustiveRegionLimit = 40
+)
+
+// cardinalRegions is the fallback for cities without stored neighborhoods.
+var cardinalRegions = []string{"centro", "norte", "sul", "leste", "oeste"}
+
+func validDepth(depth string) bool {
+	return depth == "" || depth == DepthQuick || depth == DepthStandard || depth == DepthExhaustive
+}
+
+// ExpandRegions returns the sub-queries appended to the city name for the given depth.
+// The empty region, the city on its own, always comes first.
+//
+//   - quick: the city only
+//   - standard: up to 5 stored neighborhoods, or the cardinal regions
+//   - exhaustive: every stored neighborhood plus the cardinal regions
+func ExpandRegions(city string, depth string) ([]string, error) {
+	regions := []string{""}
+	if depth == DepthQuick {
+		return regions, nil
+	}
+
+	var stored []models.CityRegion
+	if err := database.DB.Where("city = ? AND active = ?", textutil.CityKey(city), true).
+		Order("position ASC, id ASC").Find(&stored).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch city regions: %w", err)
+	}
+
+	if depth == DepthExhaustive {
+		seen := map[string]bool{}
+		for _, region := range stored {
+			seen[textutil.Fold(region.Name)] = true
+			regions = append(regions, region.Name)
+		}
+		for _, region := range cardinalRegions {
+			if !seen[region] {
+				regions = append(regions, region)
+			}
+		}
+		return regions[:min(len(regions), exhaustiveRegionLimit+1)], nil
+	}
+
+	if len(stored) == 0 {
+		return append(regions, cardinalRegions...), nil
+	}
+
+	for _, region := range stored[:min(len(stored), standardRegionLimit)] {
+		regions = append(regions, region.Name)
+	}
+	return regions, nil
+}
+
+// Price quotes the credits of a search with the current pricing, proportional to the
+// number of queries it sends to the provider plus the options requested. Reserve
+// Reserved() of them before running it.
+func Price(cityReq CityRequest) (models.CreditQuote, error) {
+	queries, err := plan(cityReq)
+	if err != nil {
+		return models.CreditQuote{}, err
+	}
+
+	config, err := pricing.Current()
+	if err != nil {
+		return models.CreditQuote{}, err
+	}
+
+	return pricing.Quote(config, len(queries), !cityReq.SkipDetails, cityReq.Enrich), nil
+}
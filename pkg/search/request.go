@@ -0,0 +1,68 @@
+package search
+
+import (
+	"fmt"
+	"medina-consultancy-api/pkg/phone"
+	"strings"
+)
+
+type CityRequest struct {
+	Search        string   `json:"search"`
+	City          string   `json:"city"`
+	Area          *Area    `json:"area"`  // searches a geographic area instead of the city regions
+	Depth         string   `json:"depth"` // quick, standard or exhaustive, defaults to standard
+	PlaceType     string   `json:"place_type"`
+	MinRating     float64  `json:"min_rating"`
+	MinReviews    int      `json:"min_reviews"`
+	PriceLevel    int      `json:"price_level"` // maximum price level, 1-4 (4=very expensive), 0 disables the filter
+	ExcludeClosed bool     `json:"exclude_closed"`
+	Keywords      []string `json:"keywords"`
+	ForceRefresh  bool     `json:"force_refresh"` // skips the re-use of a recent identical search
+	Enrich        bool     `json:"enrich"`        // visits the websites for emails and social links, costs EnrichmentCredits more
+	Country       string   `json:"country"`       // ISO code used to read local phone numbers, defaults to BR
+	OnlyMobile    bool     `json:"only_mobile"`   // keeps places with a mobile phone
+	OnlyNew       bool     `json:"only_new"`      // leaves out places delivered by earlier searches, see ExcludeSeen
+	SkipDetails   bool     `json:"skip_details"`  // no phone or website, saves the details credits
+}
+
+func (r CityRequest) Validate() error {
+	if r.Search == "" || (r.City == "" && r.Area == nil) {
+		return fmt.Errorf("Search and city fields are required")
+	}
+
+	if !validDepth(r.Depth) {
+		return fmt.Errorf("depth must be quick, standard or exhaustive")
+	}
+
+	if r.Area != nil {
+		if _, err := r.Area.shape(); err != nil {
+			return err
+		}
+	}
+
+	if r.SkipDetails && (r.OnlyMobile || r.Enrich) {
+		return fmt.Errorf("only_mobile and enrich need the place details, remove skip_details")
+	}
+
+	if r.Country != "" && !phone.KnownCountry(r.Country) {
+		return fmt.Errorf("unsupported country %q", r.Country)
+	}
+
+	return nil
+}
+
+// Location is the human readable place searched, stored as the search city.
+func (r CityRequest) Location() string {
+	if r.City != "" {
+		return r.City
+	}
+	return r.Area.label()
+}
+
+// PhoneCountry is the country of the phone numbers found, BR unless given.
+func (r CityRequest) PhoneCountry() string {
+	if r.Country == "" {
+		return phone.DefaultCountry
+	}
+	return strings.ToUpper(r.Country)
+}
@@ -0,0 +1,254 @@
+package search
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"medina-consultancy-api/database"
+	"medina-consultancy-api/models"
+	"medina-consultancy-api/pkg/phone"
+	"medina-consultancy-api/pkg/places"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// placeColumns are refreshed when a place that already exists is returned again.
+var placeColumns = []string{
+	"name", "formatted_address", "url", "rating", "user_ratings_total", "price_level",
+	"business_status", "types", "lat", "lng", "updated_at",
+}
+
+// detailsColumns come from Place Details, which a search may skip or fail to fetch, so
+// they are only refreshed when the value they follow is not empty. The columns derived
+// from the phone follow the phone, open_now follows the weekday hours.
+var detailsColumns = [][2]string{
+	{"formatted_phone_number", "formatted_phone_number"},
+	{"phone_e164", "formatted_phone_number"},
+	{"phone_type", "formatted_phone_number"},
+	{"whatsapp_likely", "formatted_phone_number"},
+	{"website", "website"},
+	{"weekday_text", "weekday_text"},
+	{"open_now", "weekday_text"},
+}
+
+// enrichmentColumns are only refreshed by results that were enriched, so a plain search
+// does not erase the contacts found by an earlier one.
+var enrichmentColumns = []string{"emails", "website_phones", "social_links", "whatsapp", "enriched_at"}
+
+func placeUpdates() clause.Set {
+	updates := clause.AssignmentColumns(placeColumns)
+	for _, pair := range detailsColumns {
+		column, source := pair[0], pair[1]
+		updates = append(updates, clause.Assignment{
+			Column: clause.Column{Name: column},
+			Value:  gorm.Expr(fmt.Sprintf("CASE WHEN COALESCE(excluded.%s, '') = '' THEN places.%s ELSE excluded.%s END", source, column, column)),
+		})
+	}
+	for _, column := range enrichmentColumns {
+		updates = append(updates, clause.Assignment{
+			Column: clause.Column{Name: column},
+			Value:  gorm.Expr(fmt.Sprintf("CASE WHEN excluded.enriched_at IS NULL THEN places.%s ELSE excluded.%s END", column, column)),
+		})
+	}
+	return updates
+}
+
+// SaveSearch creates the search record together with its results, which become seen by
+// the user.
+func SaveSearch(record *models.Search, providerName string, results []places.PlaceDetails) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(record).Error; err != nil {
+			return fmt.Errorf("failed to save search record: %w", err)
+		}
+		if err := SaveResults(tx, providerName, record.SearchID, results); err != nil {
+			return err
+		}
+		return MarkSeen(tx, Owner{UserID: record.UserID}, record.SearchID)
+	})
+}
+
+// IntegrationQueryRecord is the billing record of an integration query that ran now.
+func IntegrationQueryRecord(subscriptionID uint, userID uint, searchID string, cityReq CityRequest, results int, bucketURL string) *models.IntegrationQuery {
+	return &models.IntegrationQuery{
+		SubscriptionID: subscriptionID,
+		UserID:         userID,
+		SearchID:       searchID,
+		Query:          cityReq.Search,
+		City:           cityReq.Location(),
+		Results:        results,
+		BucketURL:      bucketURL,
+		BillingMonth:   time.Now().Format("2006-01"),
+		Fingerprint:    cityReq.Fingerprint(),
+	}
+}
+
+// ReusedIntegrationQueryRecord is the billing record of a query answered now with the
+// results of original, under a new search ID.
+func ReusedIntegrationQueryRecord(original *models.IntegrationQuery) *models.IntegrationQuery {
+	return &models.IntegrationQuery{
+		SubscriptionID: original.SubscriptionID,
+		UserID:         original.UserID,
+		SearchID:       uuid.New().String(),
+		Query:          original.Query,
+		City:           original.City,
+		Results:        original.Results,
+		BucketURL:      original.BucketURL,
+		BillingMonth:   time.Now().Format("2006-01"),
+		Fingerprint:    original.Fingerprint,
+		Reused:         true,
+		ReusedFrom:     original.SearchID,
+	}
+}
+
+// SaveResults upserts the places returned by a search and links them to it, keeping the
+// order in which they were returned. Run it in the same transaction as the search record.
+func SaveResults(tx *gorm.DB, providerName string, searchID string, results []places.PlaceDetails) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	rows := make([]models.Place, 0, len(results))
+	seen := make(map[string]bool, len(results))
+	for _, result := range results {
+		row := placeRow(providerName, result)
+		if seen[row.ExternalID] {
+			continue
+		}
+		seen[row.ExternalID] = true
+		rows = append(rows, row)
+	}
+
+	err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "provider"}, {Name: "external_id"}},
+		DoUpdates: placeUpdates(),
+	}).CreateInBatches(&rows, 200).Error
+	if err != nil {
+		return fmt.Errorf("failed to save places: %w", err)
+	}
+
+	links := make([]models.SearchResult, len(rows))
+	for i, row := range rows {
+		links[i] = models.SearchResult{SearchID: searchID, PlaceID: row.ID, Position: i}
+	}
+
+	if err := tx.CreateInBatches(&links, 500).Error; err != nil {
+		return fmt.Errorf("failed to save search results: %w", err)
+	}
+
+	return nil
+}
+
+// ResultsQuery selects the places of a search in their original order, ready to be
+// filtered or paginated further. Finds must Select("places.*") to skip the joined columns.
+func ResultsQuery(searchID string) *gorm.DB {
+	return database.DB.Model(&models.Place{}).
+		Joins("JOIN search_results ON search_results.place_id = places.id").
+		Where("search_results.search_id = ?", searchID).
+		Order("search_results.position ASC")
+}
+
+// LoadResults returns the stored places of a search. ok is false for searches made before
+// results were persisted, those only exist as CSV.
+func LoadResults(searchID string) (results []places.PlaceDetails, ok bool, err error) {
+	var count int64
+	if err := database.DB.Model(&models.SearchResult{}).Where("search_id = ?", searchID).Count(&count).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to count search results: %w", err)
+	}
+	if count == 0 {
+		return nil, false, nil
+	}
+
+	var rows []models.Place
+	if err := ResultsQuery(searchID).Select("places.*").Find(&rows).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to load search results: %w", err)
+	}
+
+	return PlaceDetailsFromRows(rows), true, nil
+}
+
+func PlaceDetailsFromRows(rows []models.Place) []places.PlaceDetails {
+	results := make([]places.PlaceDetails, len(rows))
+	for i, row := range rows {
+		results[i] = places.PlaceDetails{
+			PlaceID:              row.ExternalID,
+			Name:                 row.Name,
+			FormattedAddress:     row.FormattedAddress,
+			FormattedPhoneNumber: row.FormattedPhoneNumber,
+			Website:              row.Website,
+			URL:                  row.URL,
+			Rating:               row.Rating,
+			UserRatingsTotal:     row.UserRatingsTotal,
+			PriceLevel:           row.PriceLevel,
+			BusinessStatus:       row.BusinessStatus,
+			Types:                row.Types,
+			Lat:                  row.Lat,
+			Lng:                  row.Lng,
+			PhoneE164:            row.PhoneE164,
+			PhoneType:            row.PhoneType,
+			WhatsAppLikely:       row.WhatsAppLikely,
+			Emails:               row.Emails,
+			WebsitePhones:        row.WebsitePhones,
+			SocialLinks:          row.SocialLinks,
+			WhatsApp:             row.WhatsApp,
+			EnrichedAt:           row.EnrichedAt,
+		}
+		if row.OpenNow || len(row.WeekdayText) > 0 {
+			results[i].OpeningHours = &places.OpeningHours{OpenNow: row.OpenNow, WeekdayText: row.WeekdayText}
+		}
+		// places saved before phones were classified
+		if row.PhoneType == "" {
+			ClassifyPhone(&results[i], phone.DefaultCountry)
+		}
+	}
+	return results
+}
+
+func placeRow(providerName string, place places.PlaceDetails) models.Place {
+	row := models.Place{
+		Provider:             providerName,
+		ExternalID:           place.PlaceID,
+		Name:                 place.Name,
+		FormattedAddress:     place.FormattedAddress,
+		FormattedPhoneNumber: place.FormattedPhoneNumber,
+		Website:              place.Website,
+		URL:                  place.URL,
+		Rating:               place.Rating,
+		UserRatingsTotal:     place.UserRatingsTotal,
+		PriceLevel:           place.PriceLevel,
+		BusinessStatus:       place.BusinessStatus,
+		Types:                place.Types,
+		Lat:                  place.Lat,
+		Lng:                  place.Lng,
+		PhoneE164:            place.PhoneE164,
+		PhoneType:            place.PhoneType,
+		WhatsAppLikely:       place.WhatsAppLikely,
+		Emails:               place.Emails,
+		WebsitePhones:        place.WebsitePhones,
+		SocialLinks:          place.SocialLinks,
+		WhatsApp:             place.WhatsApp,
+		EnrichedAt:           place.EnrichedAt,
+	}
+
+	if place.OpeningHours != nil {
+		row.OpenNow = place.OpeningHours.OpenNow
+		row.WeekdayText = place.OpeningHours.WeekdayText
+	}
+
+	row.ExternalID = placeKey(place.PlaceID, place.Name, place.FormattedAddress)
+
+	return row
+}
+
+// placeKey is the provider ID of a place; places without one are keyed by their name and
+// address.
+func placeKey(placeID, name, address string) string {
+	if placeID != "" {
+		return placeID
+	}
+	sum := sha1.Sum([]byte(strings.ToLower(name + "|" + address)))
+	return "anon:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,181 @@
+package search
+
+import (
+	"context"
+	"medina-consultancy-api/database"
+	"medina-consultancy-api/models"
+	"medina-consultancy-api/pkg/places"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// sqlRecorder is a gorm logger keeping the statements it is given.
+type sqlRecorder struct {
+	logger.Interface
+	statements []string
+}
+
+func (r *sqlRecorder) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	sql, _ := fc()
+	r.statements = append(r.statements, sql)
+}
+
+// dryRunDB builds Postgres statements without a server.
+func dryRunDB(t *testing.T) (*gorm.DB, *sqlRecorder) {
+	recorder := &sqlRecorder{Interface: logger.Discard}
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+		Logger:                 recorder,
+	})
+	if err != nil {
+		t.Fatalf("gorm.Open: %v", err)
+	}
+	return db, recorder
+}
+
+func TestSaveResultsKeepsDetails(t *testing.T) {
+	db, recorder := dryRunDB(t)
+
+	results := []places.PlaceDetails{{PlaceID: "abc", Name: "Padaria"}}
+	if err := SaveResults(db, "google", "search-1", results); err != nil {
+		t.Fatalf("SaveResults: %v", err)
+	}
+	if len(recorder.statements) == 0 {
+		t.Fatal("no statement recorded")
+	}
+	upsert := recorder.statements[0]
+
+	guarded := []string{
+		`"formatted_phone_number"=CASE WHEN COALESCE(excluded.formatted_phone_number, '') = '' THEN places.formatted_phone_number ELSE excluded.formatted_phone_number END`,
+		`"phone_e164"=CASE WHEN COALESCE(excluded.formatted_phone_number, '') = '' THEN places.phone_e164 ELSE excluded.phone_e164 END`,
+		`"phone_type"=CASE WHEN COALESCE(excluded.formatted_phone_number, '') = '' THEN places.phone_type ELSE excluded.phone_type END`,
+		`"whatsapp_likely"=CASE WHEN COALESCE(excluded.formatted_phone_number, '') = '' THEN places.whatsapp_likely ELSE excluded.whatsapp_likely END`,
+		`"website"=CASE WHEN COALESCE(excluded.website, '') = '' THEN places.website ELSE excluded.website END`,
+		`"weekday_text"=CASE WHEN COALESCE(excluded.weekday_text, '') = '' THEN places.weekday_text ELSE excluded.weekday_text END`,
+		`"open_now"=CASE WHEN COALESCE(excluded.weekday_text, '') = '' THEN places.open_now ELSE excluded.open_now END`,
+		`"emails"=CASE WHEN excluded.enriched_at IS NULL THEN places.emails ELSE excluded.emails END`,
+	}
+	for _, assignment := range guarded {
+		if !strings.Contains(upsert, assignment) {
+			t.Errorf("upsert is missing %s\n%s", assignment, upsert)
+		}
+	}
+
+	for _, column := range []string{"formatted_phone_number", "website", "phone_e164", "weekday_text", "open_now"} {
+		if strings.Contains(upsert, `"`+column+`"="excluded"."`+column+`"`) {
+			t.Errorf("upsert overwrites %s unconditionally", column)
+		}
+	}
+}
+
+func testPlace() places.PlaceDetails {
+	enrichedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	return places.PlaceDetails{
+		PlaceID:              "abc",
+		Name:                 "Padaria",
+		FormattedAddress:     "Rua A, 1 - São Paulo",
+		FormattedPhoneNumber: "(11) 91234-5678",
+		Website:              "https://padaria.com.br",
+		URL:                  "https://www.google.com/maps/place/?q=place_id:abc",
+		Rating:               4.5,
+		UserRatingsTotal:     120,
+		PriceLevel:           2,
+		BusinessStatus:       "OPERATIONAL",
+		OpeningHours:         &places.OpeningHours{OpenNow: true, WeekdayText: []string{"segunda-feira: 07:00–20:00", "terça-feira: 07:00–20:00"}},
+		Types:                []string{"bakery", "store"},
+		Lat:                  -23.55,
+		Lng:                  -46.63,
+		PhoneE164:            "+5511912345678",
+		PhoneType:            "mobile",
+		WhatsAppLikely:       true,
+		Emails:               []string{"contato@padaria.com.br"},
+		SocialLinks:          map[string]string{"instagram": "https://instagram.com/padaria"},
+		EnrichedAt:           &enrichedAt,
+	}
+}
+
+func TestPlaceRowRoundTrip(t *testing.T) {
+	place := testPlace()
+
+	got := PlaceDetailsFromRows([]models.Place{placeRow("google", place)})
+	if !reflect.DeepEqual(got[0], place) {
+		t.Errorf("round trip changed the place\n got %+v\nwant %+v", got[0], place)
+	}
+	if hours := got[0].OpeningHours; hours == nil || !hours.OpenNow || len(hours.WeekdayText) != 2 {
+		t.Errorf("OpeningHours = %+v, want the stored hours", hours)
+	}
+
+	place.OpeningHours = nil
+	if got := PlaceDetailsFromRows([]models.Place{placeRow("google", place)}); got[0].OpeningHours != nil {
+		t.Errorf("OpeningHours = %+v, want nil for a place without hours", got[0].OpeningHours)
+	}
+}
+
+// TestSaveResultsRoundTrip needs a Postgres database in TEST_DATABASE_URL, its changes
+// are rolled back.
+func TestSaveResultsRoundTrip(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: dsn}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("gorm.Open: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Place{}, &models.SearchResult{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+
+	tx := db.Begin()
+	defer tx.Rollback()
+
+	previous := database.DB
+	database.DB = tx
+	defer func() { database.DB = previous }()
+
+	place := testPlace()
+	place.PlaceID = "round-trip-test"
+	if err := SaveResults(tx, "google", "round-trip-1", []places.PlaceDetails{place}); err != nil {
+		t.Fatalf("SaveResults: %v", err)
+	}
+
+	// a later search without details or enrichment keeps what was stored
+	bare := place
+	bare.FormattedPhoneNumber, bare.Website, bare.PhoneE164, bare.PhoneType, bare.WhatsAppLikely = "", "", "", "", false
+	bare.OpeningHours, bare.Emails, bare.SocialLinks, bare.EnrichedAt = nil, nil, nil, nil
+	bare.Rating = 4.7
+	if err := SaveResults(tx, "google", "round-trip-2", []places.PlaceDetails{bare}); err != nil {
+		t.Fatalf("SaveResults: %v", err)
+	}
+
+	for _, searchID := range []string{"round-trip-1", "round-trip-2"} {
+		results, ok, err := LoadResults(searchID)
+		if err != nil || !ok || len(results) != 1 {
+			t.Fatalf("LoadResults(%s) = %d results, %v, %v", searchID, len(results), ok, err)
+		}
+
+		got := results[0]
+		if got.Rating != 4.7 {
+			t.Errorf("%s: Rating = %v, want the refreshed 4.7", searchID, got.Rating)
+		}
+		if got.FormattedPhoneNumber != place.FormattedPhoneNumber || got.Website != place.Website || got.PhoneE164 != place.PhoneE164 {
+			t.Errorf("%s: contacts = %q/%q/%q, want the stored ones", searchID, got.FormattedPhoneNumber, got.Website, got.PhoneE164)
+		}
+		if !reflect.DeepEqual(got.OpeningHours, place.OpeningHours) {
+			t.Errorf("%s: OpeningHours = %+v, want %+v", searchID, got.OpeningHours, place.OpeningHours)
+		}
+		if !reflect.DeepEqual(got.Emails, place.Emails) {
+			t.Errorf("%s: Emails = %v, want %v", searchID, got.Emails, place.Emails)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package search
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"medina-consultancy-api/database"
+	"medina-consultancy-api/models"
+	"medina-consultancy-api/pkg/phone"
+	"medina-consultancy-api/pkg/textutil"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Fingerprint identifies requests that return the same places: text is compared without
+// case or accents, keywords in any order. ForceRefresh is not part of it.
+func (r CityRequest) Fingerprint() string {
+	keywords := make([]string, len(r.Keywords))
+	for i, keyword := range r.Keywords {
+		keywords[i] = textutil.Fold(keyword)
+	}
+	slices.Sort(keywords)
+
+	// BR is the default, keep the fingerprints of searches made before countries
+	country := r.PhoneCountry()
+	if country == phone.DefaultCountry {
+		country = ""
+	}
+
+	depth := r.Depth
+	if depth == "" {
+		depth = DepthStandard
+	}
+
+	normalized, _ := json.Marshal(struct {
+		Search        string   `json:"search"`
+		City          string   `json:"city"`
+		Area          *Area    `json:"area"`
+		Depth         string   `json:"depth"`
+		PlaceType     string   `json:"place_type"`
+		MinRating     float64  `json:"min_rating"`
+		MinReviews    int      `json:"min_reviews"`
+		PriceLevel    int      `json:"price_level"`
+		ExcludeClosed bool     `json:"exclude_closed"`
+		Keywords      []string `json:"keywords"`
+		Enrich        bool     `json:"enrich,omitempty"`
+		Country       string   `json:"country,omitempty"`
+		OnlyMobile    bool     `json:"only_mobile,omitempty"`
+		OnlyNew       bool     `json:"only_new,omitempty"`
+		SkipDetails   bool     `json:"skip_details,omitempty"`
+	}{
+		Search:        textutil.Fold(r.Search),
+		City:          textutil.CityKey(r.City),
+		Area:          r.Area,
+		Depth:         depth,
+		PlaceType:     r.PlaceType,
+		MinRating:     r.MinRating,
+		MinReviews:    r.MinReviews,
+		PriceLevel:    r.PriceLevel,
+		ExcludeClosed: r.ExcludeClosed,
+		Keywords:      keywords,
+		Enrich:        r.Enrich,
+		Country:       country,
+		OnlyMobile:    r.OnlyMobile,
+		OnlyNew:       r.OnlyNew,
+		SkipDetails:   r.SkipDetails,
+	})
+
+	sum := sha256.Sum256(normalized)
+	return hex.EncodeToString(sum[:])
+}
+
+// FindReusableSearch returns the latest search of the user with the same fingerprint made
+// within the re-use window, or nil. Searches that found nothing are never re-used, and
+// re-uses are left out so that the window counts from when the places were searched.
+func FindReusableSearch(userID uint, fingerprint string) (*models.Search, error) {
+	window := ReuseWindow()
+	if window <= 0 {
+		return nil, nil
+	}
+
+	var original models.Search
+	err := database.DB.
+		Where("user_id = ? AND fingerprint = ? AND results > 0 AND created_at > ?", userID, fingerprint, time.Now().Add(-window)).
+		Where("COALESCE(reused_from, '') = ''").
+		Order("created_at DESC").Limit(1).Find(&original).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up previous searches: %w", err)
+	}
+	if original.ID == 0 {
+		return nil, nil
+	}
+
+	return &original, nil
+}
+
+// FindReusableIntegrationQuery is FindReusableSearch for integration queries of a subscription.
+func FindReusableIntegrationQuery(subscriptionID uint, fingerprint string) (*models.IntegrationQuery, error) {
+	window := ReuseWindow()
+	if window <= 0 {
+		return nil, nil
+	}
+
+	var original models.IntegrationQuery
+	err := database.DB.
+		Where("subscription_id = ? AND fingerprint = ? AND results > 0 AND created_at > ?", subscriptionID, fingerprint, time.Now().Add(-window)).
+		Where("COALESCE(reused_from, '') = ''").
+		Order("created_at DESC").Limit(1).Find(&original).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up previous queries: %w", err)
+	}
+	if original.ID == 0 {
+		return nil, nil
+	}
+
+	return &original, nil
+}
+
+// CopyResults links the places of one search to another, keeping their order.
+func CopyResults(tx *gorm.DB, fromSearchID string, toSearchID string) error {
+	err := tx.Exec(`INSERT INTO search_results (search_id, place_id, position, created_at)
+		SELECT ?, place_id, position, ? FROM search_results WHERE search_id = ?`,
+		toSearchID, time.Now(), fromSearchID).Error
+	if err != nil {
+		return fmt.Errorf("failed to copy search results: %w", err)
+	}
+	return nil
+}
+
+// ReuseSearch records a new search that serves the results of original.
+func ReuseSearch(original *models.Search, searchID string) (*models.Search, error) {
+	record := models.Search{
+		UserID:      original.UserID,
+		SearchID:    searchID,
+		Query:       original.Query,
+		City:        original.City,
+		BucketURL:   original.BucketURL,
+		FileName:    original.FileName,
+		Results:     original.Results,
+		Fingerprint: original.Fingerprint,
+		ReusedFrom:  original.SearchID,
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&record).Error; err != nil {
+			return fmt.Errorf("failed to save search record: %w", err)
+		}
+		return CopyResults(tx, original.SearchID, searchID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
@@ -0,0 +1,389 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"medina-consultancy-api/database"
+	"medina-consultancy-api/models"
+	"medina-consultancy-api/pkg/credits"
+	"medina-consultancy-api/pkg/phone"
+	"medina-consultancy-api/pkg/places"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	ScheduleDaily   = "daily"
+	ScheduleWeekly  = "weekly"
+	ScheduleMonthly = "monthly"
+
+	ChangeNew     = "new"
+	ChangeClosed  = "closed"
+	ChangeChanged = "changed"
+)
+
+// ValidSchedule tells whether a saved search schedule is supported.
+func ValidSchedule(schedule string) bool {
+	return schedule == ScheduleDaily || schedule == ScheduleWeekly || schedule == ScheduleMonthly
+}
+
+// NextRun is when a saved search with the given schedule runs again after from.
+func NextRun(schedule string, from time.Time) time.Time {
+	switch schedule {
+	case ScheduleDaily:
+		return from.AddDate(0, 0, 1)
+	case ScheduleMonthly:
+		return from.AddDate(0, 1, 0)
+	default:
+		return from.AddDate(0, 0, 7)
+	}
+}
+
+// ProcessSavedSearches runs every saved search that is due, one at a time, until there is
+// nothing left or the context deadline gets close.
+func ProcessSavedSearches(ctx context.Context) error {
+	processed := 0
+	for {
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < jobDeadlineMargin {
+			log.Printf("Stopping saved searches close to deadline, %d run(s) processed", processed)
+			return nil
+		}
+
+		saved, err := claimDueSavedSearch()
+		if err != nil {
+			return err
+		}
+		if saved == nil {
+			log.Printf("No saved searches due, %d run(s) processed", processed)
+			return nil
+		}
+
+		run := runSavedSearch(ctx, saved)
+		if err := database.DB.Save(run).Error; err != nil {
+			log.Printf("Failed to save run of saved search %d: %v", saved.ID, err)
+		}
+		processed++
+	}
+}
+
+// claimDueSavedSearch moves the next run of a due saved search forward before running it,
+// so that concurrent schedulers never run it twice.
+func claimDueSavedSearch() (*models.SavedSearch, error) {
+	for {
+		var saved models.SavedSearch
+		err := database.DB.Where("active = ? AND next_run_at <= ?", true, time.Now()).Order("next_run_at ASC").Limit(1).Find(&saved).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch due saved searches: %w", err)
+		}
+		if saved.ID == 0 {
+			return nil, nil
+		}
+
+		now := time.Now()
+		result := database.DB.Model(&models.SavedSearch{}).
+			Where("id = ? AND next_run_at = ?", saved.ID, saved.NextRunAt).
+			Updates(map[string]interface{}{
+				"next_run_at": NextRun(saved.Schedule, now),
+				"last_run_at": now,
+			})
+		if result.Error != nil {
+			return nil, fmt.Errorf("failed to claim saved search %d: %w", saved.ID, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		saved.LastRunAt = &now
+		return &saved, nil
+	}
+}
+
+// runSavedSearch searches again and compares the places found with the previous run.
+// Failures are reported on the returned run instead of as errors. The run is stored as
+// running first so that the stale reservation sweep leaves its credits alone.
+func runSavedSearch(ctx context.Context, saved *models.SavedSearch) *models.SavedSearchRun {
+	run := &models.SavedSearchRun{SavedSearchID: saved.ID, SearchID: uuid.New().String(), Status: "running"}
+
+	fail := func(status string, cause error) *models.SavedSearchRun {
+		log.Printf("Saved search %d run %s: %v", saved.ID, status, cause)
+		run.Status = status
+		run.Error = cause.Error()
+		run.CreditsUsed = 0
+		return run
+	}
+
+	var cityReq CityRequest
+	if err := json.Unmarshal(saved.Request, &cityReq); err != nil {
+		return fail("failed", fmt.Errorf("invalid search request: %w", err))
+	}
+	cityReq.ForceRefresh = true
+
+	if err := database.DB.Create(run).Error; err != nil {
+		return fail("failed", fmt.Errorf("failed to save run: %w", err))
+	}
+
+	var quote models.CreditQuote
+	if saved.SubscriptionID != nil {
+		var subscription models.Subscription
+		if err := database.DB.First(&subscription, *saved.SubscriptionID).Error; err != nil || subscription.Status != "active" {
+			return fail("skipped", fmt.Errorf("subscription is not active"))
+		}
+	} else {
+		var err error
+		quote, err = Price(cityReq)
+		if err != nil {
+			return fail("failed", err)
+		}
+		cost := quote.Reserved()
+
+		_, err = credits.Reserve(saved.UserID, cost, "saved_search", run.SearchID, "Saved search: "+saved.Name)
+		if errors.Is(err, credits.ErrInsufficientCredits) {
+			return fail("skipped", fmt.Errorf("insufficient credits, %d required", cost))
+		}
+		if err != nil {
+			return fail("failed", fmt.Errorf("failed to reserve credits: %w", err))
+		}
+		run.CreditsUsed = cost
+	}
+
+	release := func(status string, cause error) *models.SavedSearchRun {
+		if run.CreditsUsed > 0 {
+			if _, err := credits.ReleaseReservation("saved_search", run.SearchID, cause.Error()); err != nil {
+				log.Printf("Failed to refund credits for search %s: %v", run.SearchID, err)
+			}
+		}
+		return fail(status, cause)
+	}
+
+	provider, err := places.NewProviderFromEnv()
+	if err != nil {
+		return release("failed", fmt.Errorf("failed to configure place provider: %w", err))
+	}
+
+	result, err := Run(ctx, provider, cityReq, nil)
+	if err != nil {
+		return release("failed", err)
+	}
+	results := result.Places
+
+	fileName, bucketURL, err := UploadCSV(run.SearchID, results)
+	if err != nil {
+		return release("failed", err)
+	}
+
+	var previous models.SavedSearchRun
+	err = database.DB.Where("saved_search_id = ? AND status = ?", saved.ID, "done").Order("id DESC").Limit(1).Find(&previous).Error
+	if err != nil {
+		return release("failed", fmt.Errorf("failed to load previous run: %w", err))
+	}
+
+	run.Status = "done"
+	run.Results = len(results)
+	run.Snapshot = keepKnownContacts(Snapshot(results), previous.Snapshot)
+	if previous.ID == 0 {
+		run.Baseline = true
+	} else {
+		run.Changes = DiffResults(previous.Snapshot, results)
+		for _, change := range run.Changes {
+			switch change.Change {
+			case ChangeNew:
+				run.NewPlaces++
+			case ChangeClosed:
+				run.ClosedPlaces++
+			case ChangeChanged:
+				run.ChangedPlaces++
+			}
+		}
+	}
+
+	refund := 0
+	if run.CreditsUsed > 0 {
+		refund = SearchRefund(result, quote)
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if saved.SubscriptionID != nil {
+			err := tx.Create(IntegrationQueryRecord(*saved.SubscriptionID, saved.UserID, run.SearchID, cityReq, len(results), bucketURL)).Error
+			if err != nil {
+				return fmt.Errorf("failed to save integration query record: %w", err)
+			}
+		} else {
+			err := tx.Create(&models.Search{
+				UserID:          saved.UserID,
+				SearchID:        run.SearchID,
+				Query:           cityReq.Search,
+				City:            cityReq.Location(),
+				BucketURL:       bucketURL,
+				FileName:        fileName,
+				Results:         len(results),
+				Fingerprint:     cityReq.Fingerprint(),
+				Warnings:        result.Meta.Warnings,
+				DetailsMissing:  result.Meta.DetailsMissing,
+				CreditsRefunded: refund,
+			}).Error
+			if err != nil {
+				return fmt.Errorf("failed to save search record: %w", err)
+			}
+		}
+
+		if err := SaveResults(tx, provider.Name(), run.SearchID, results); err != nil {
+			return err
+		}
+		owner := Owner{UserID: saved.UserID}
+		if saved.SubscriptionID != nil {
+			owner.SubscriptionID = *saved.SubscriptionID
+		}
+		if err := MarkSeen(tx, owner, run.SearchID); err != nil {
+			return err
+		}
+
+		return tx.Model(&models.SavedSearch{}).Where("id = ?", saved.ID).Updates(map[string]interface{}{
+			"last_search_id": run.SearchID,
+			"runs":           gorm.Expr("runs + 1"),
+		}).Error
+	})
+	if err != nil {
+		return release("failed", err)
+	}
+
+	if run.CreditsUsed > 0 {
+		run.CreditsUsed, _ = SettleSearchCredits("saved_search", run.SearchID, run.CreditsUsed, refund)
+	}
+
+	log.Printf("Saved search %d: %d results, %d new, %d closed, %d changed", saved.ID, run.Results, run.NewPlaces, run.ClosedPlaces, run.ChangedPlaces)
+
+	return run
+}
+
+// failStaleSavedRuns fails the runs left running by a dead scheduler, releasing their
+// reservations to the sweep.
+func failStaleSavedRuns() {
+	database.DB.Model(&models.SavedSearchRun{}).
+		Where("status = ? AND created_at < ?", "running", time.Now().Add(-staleJobTimeout)).
+		Updates(map[string]interface{}{"status": "failed", "error": "run timed out", "credits_used": 0})
+}
+
+// Snapshot keeps the fields of the results compared by the next run.
+func Snapshot(results []places.PlaceDetails) []models.PlaceSnapshot {
+	snapshot := make([]models.PlaceSnapshot, len(results))
+	for i, place := range results {
+		snapshot[i] = models.PlaceSnapshot{
+			PlaceID:        placeKey(place.PlaceID, place.Name, place.FormattedAddress),
+			Name:           place.Name,
+			Address:        place.FormattedAddress,
+			Phone:          place.FormattedPhoneNumber,
+			Website:        place.Website,
+			BusinessStatus: place.BusinessStatus,
+		}
+	}
+	return snapshot
+}
+
+// keepKnownContacts fills the phones and websites missing from the snapshot, whose details
+// failed this time, with the previous ones so that the next run compares against them.
+func keepKnownContacts(snapshot []models.PlaceSnapshot, previous []models.PlaceSnapshot) []models.PlaceSnapshot {
+	before := make(map[string]models.PlaceSnapshot, len(previous))
+	for _, place := range previous {
+		before[place.PlaceID] = place
+	}
+
+	for i, place := range snapshot {
+		old := before[place.PlaceID]
+		if place.Phone == "" {
+			snapshot[i].Phone = old.Phone
+		}
+		if place.Website == "" {
+			snapshot[i].Website = old.Website
+		}
+	}
+	return snapshot
+}
+
+// DiffResults lists the places that are new since the previous run, closed (by status or
+// because they are no longer returned) and those whose phone or website changed. An empty
+// phone or website is not a change, the details of the place may have failed.
+func DiffResults(previous []models.PlaceSnapshot, current []places.PlaceDetails) []models.PlaceChange {
+	before := make(map[string]models.PlaceSnapshot, len(previous))
+	for _, place := range previous {
+		before[place.PlaceID] = place
+	}
+
+	var changes []models.PlaceChange
+	seen := make(map[string]bool, len(current))
+
+	for _, place := range Snapshot(current) {
+		seen[place.PlaceID] = true
+		change := models.PlaceChange{
+			PlaceID:        place.PlaceID,
+			Name:           place.Name,
+			Address:        place.Address,
+			Phone:          place.Phone,
+			Website:        place.Website,
+			BusinessStatus: place.BusinessStatus,
+		}
+
+		old, existed := before[place.PlaceID]
+		switch {
+		case !existed:
+			change.Change = ChangeNew
+		case isClosed(place.BusinessStatus) && !isClosed(old.BusinessStatus):
+			change.Change = ChangeClosed
+		case (place.Phone != "" && !samePhone(old.Phone, place.Phone)) ||
+			(place.Website != "" && !sameWebsite(old.Website, place.Website)):
+			change.Change = ChangeChanged
+			change.PreviousPhone = old.Phone
+			change.PreviousWebsite = old.Website
+		default:
+			continue
+		}
+
+		changes = append(changes, change)
+	}
+
+	for _, old := range previous {
+		if seen[old.PlaceID] || isClosed(old.BusinessStatus) {
+			continue
+		}
+		changes = append(changes, models.PlaceChange{
+			Change:         ChangeClosed,
+			PlaceID:        old.PlaceID,
+			Name:           old.Name,
+			Address:        old.Address,
+			Phone:          old.Phone,
+			Website:        old.Website,
+			BusinessStatus: old.BusinessStatus,
+			Missing:        true,
+		})
+	}
+
+	return changes
+}
+
+func isClosed(status string) bool {
+	return status == "CLOSED_TEMPORARILY" || status == "CLOSED_PERMANENTLY"
+}
+
+// samePhone compares numbers in E.164 when both can be normalized, so that formatting
+// changes are not reported.
+func samePhone(a, b string) bool {
+	if normalizedA, normalizedB := phone.E164(a), phone.E164(b); normalizedA != "" && normalizedB != "" {
+		return normalizedA == normalizedB
+	}
+	return strings.TrimSpace(a) == strings.TrimSpace(b)
+}
+
+// sameWebsite ignores the scheme, a leading www. and a trailing slash.
+func sameWebsite(a, b string) bool {
+	normalize := func(website string) string {
+		website = strings.ToLower(strings.TrimSpace(website))
+		website = strings.TrimPrefix(strings.TrimPrefix(website, "https://"), "http://")
+		return strings.TrimSuffix(strings.TrimPrefix(website, "www."), "/")
+	}
+	return normalize(a) == normalize(b)
+}
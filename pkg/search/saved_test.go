@@ -0,0 +1,114 @@
+package search
+
+import (
+	"medina-consultancy-api/models"
+	"medina-consultancy-api/pkg/places"
+	"reflect"
+	"testing"
+)
+
+func TestDiffResults(t *testing.T) {
+	previous := []models.PlaceSnapshot{
+		{PlaceID: "a", Name: "Padaria", Phone: "(11) 91234-5678", Website: "https://padaria.com.br", BusinessStatus: "OPERATIONAL"},
+		{PlaceID: "b", Name: "Mercado", Phone: "(11) 3456-7890", BusinessStatus: "OPERATIONAL"},
+	}
+	padaria := places.PlaceDetails{PlaceID: "a", Name: "Padaria", FormattedPhoneNumber: "(11) 91234-5678", Website: "https://padaria.com.br", BusinessStatus: "OPERATIONAL"}
+	mercado := places.PlaceDetails{PlaceID: "b", Name: "Mercado", FormattedPhoneNumber: "(11) 3456-7890", BusinessStatus: "OPERATIONAL"}
+
+	with := func(place places.PlaceDetails, change func(*places.PlaceDetails)) places.PlaceDetails {
+		change(&place)
+		return place
+	}
+
+	tests := []struct {
+		name    string
+		current []places.PlaceDetails
+		want    map[string]string // change by place ID
+	}{
+		{"unchanged", []places.PlaceDetails{padaria, mercado}, map[string]string{}},
+		{
+			"new place",
+			[]places.PlaceDetails{padaria, mercado, {PlaceID: "c", Name: "Açougue"}},
+			map[string]string{"c": ChangeNew},
+		},
+		{
+			"closed by status",
+			[]places.PlaceDetails{padaria, with(mercado, func(p *places.PlaceDetails) { p.BusinessStatus = "CLOSED_PERMANENTLY" })},
+			map[string]string{"b": ChangeClosed},
+		},
+		{"closed because missing", []places.PlaceDetails{padaria}, map[string]string{"b": ChangeClosed}},
+		{
+			"phone changed",
+			[]places.PlaceDetails{with(padaria, func(p *places.PlaceDetails) { p.FormattedPhoneNumber = "(11) 98888-7777" }), mercado},
+			map[string]string{"a": ChangeChanged},
+		},
+		{
+			"website changed",
+			[]places.PlaceDetails{with(padaria, func(p *places.PlaceDetails) { p.Website = "https://padaria.net" }), mercado},
+			map[string]string{"a": ChangeChanged},
+		},
+		{
+			"website added",
+			[]places.PlaceDetails{padaria, with(mercado, func(p *places.PlaceDetails) { p.Website = "https://mercado.com.br" })},
+			map[string]string{"b": ChangeChanged},
+		},
+		{
+			"formatting only",
+			[]places.PlaceDetails{with(padaria, func(p *places.PlaceDetails) {
+				p.FormattedPhoneNumber = "+55 11 91234-5678"
+				p.Website = "http://www.padaria.com.br/"
+			}), mercado},
+			map[string]string{},
+		},
+		{
+			"details missing",
+			[]places.PlaceDetails{with(padaria, func(p *places.PlaceDetails) {
+				p.FormattedPhoneNumber = ""
+				p.Website = ""
+			}), with(mercado, func(p *places.PlaceDetails) { p.FormattedPhoneNumber = "" })},
+			map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]string{}
+			for _, change := range DiffResults(previous, tt.current) {
+				got[change.PlaceID] = change.Change
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffResults = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffResultsChangedKeepsPrevious(t *testing.T) {
+	previous := []models.PlaceSnapshot{{PlaceID: "a", Phone: "(11) 91234-5678", Website: "https://padaria.com.br"}}
+	current := []places.PlaceDetails{{PlaceID: "a", FormattedPhoneNumber: "(11) 98888-7777", Website: "https://padaria.com.br"}}
+
+	changes := DiffResults(previous, current)
+	if len(changes) != 1 {
+		t.Fatalf("DiffResults = %d changes, want 1", len(changes))
+	}
+	if changes[0].PreviousPhone != "(11) 91234-5678" || changes[0].Phone != "(11) 98888-7777" {
+		t.Errorf("change = %+v, want the previous and the new phone", changes[0])
+	}
+}
+
+func TestKeepKnownContacts(t *testing.T) {
+	previous := []models.PlaceSnapshot{{PlaceID: "a", Phone: "(11) 91234-5678", Website: "https://padaria.com.br"}}
+	snapshot := []models.PlaceSnapshot{
+		{PlaceID: "a", Website: "https://padaria.net"},
+		{PlaceID: "b"},
+	}
+
+	got := keepKnownContacts(snapshot, previous)
+	want := []models.PlaceSnapshot{
+		{PlaceID: "a", Phone: "(11) 91234-5678", Website: "https://padaria.net"},
+		{PlaceID: "b"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("keepKnownContacts = %+v, want %+v", got, want)
+	}
+}
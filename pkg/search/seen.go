@@ -0,0 +1,101 @@
+package search
+
+import (
+	"fmt"
+	"medina-consultancy-api/database"
+	"medina-consultancy-api/models"
+	"medina-consultancy-api/pkg/places"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// seenLookupBatch bounds the IN list of a single seen places lookup.
+const seenLookupBatch = 1000
+
+// Owner is who the results of a search are delivered to. Searches made in the app and
+// those of each integration subscription have separate seen places.
+type Owner struct {
+	UserID         uint
+	SubscriptionID uint // 0 outside of the integration API
+}
+
+// MarkSeen adds the results saved for searchID to the places seen by owner. Run it in the
+// same transaction as SaveResults.
+func MarkSeen(tx *gorm.DB, owner Owner, searchID string) error {
+	err := tx.Exec(`INSERT INTO seen_places (user_id, subscription_id, place_id, first_search_id, created_at)
+		SELECT ?, ?, place_id, search_id, ? FROM search_results WHERE search_id = ?
+		ON CONFLICT (user_id, subscription_id, place_id) DO NOTHING`,
+		owner.UserID, owner.SubscriptionID, time.Now(), searchID).Error
+	if err != nil {
+		return fmt.Errorf("failed to mark places as seen: %w", err)
+	}
+	return nil
+}
+
+// ExcludeSeen drops from the result the places already delivered to owner by an earlier
+// search, counting them in Meta.ExcludedSeen.
+func ExcludeSeen(result *Result, providerName string, owner Owner) error {
+	keys := make([]string, len(result.Places))
+	for i, place := range result.Places {
+		keys[i] = placeKey(place.PlaceID, place.Name, place.FormattedAddress)
+	}
+
+	seen := make(map[string]bool)
+	for batch := range slices.Chunk(keys, seenLookupBatch) {
+		var externalIDs []string
+		err := database.DB.Model(&models.SeenPlace{}).
+			Joins("JOIN places ON places.id = seen_places.place_id").
+			Where("seen_places.user_id = ? AND seen_places.subscription_id = ?", owner.UserID, owner.SubscriptionID).
+			Where("places.provider = ? AND places.external_id IN ?", providerName, batch).
+			Pluck("places.external_id", &externalIDs).Error
+		if err != nil {
+			return fmt.Errorf("failed to look up seen places: %w", err)
+		}
+		for _, externalID := range externalIDs {
+			seen[externalID] = true
+		}
+	}
+
+	kept := make([]places.PlaceDetails, 0, len(result.Places))
+	for i, place := range result.Places {
+		if !seen[keys[i]] {
+			kept = append(kept, place)
+		}
+	}
+
+	result.Meta.ExcludedSeen = len(result.Places) - len(kept)
+	result.Places = kept
+	return nil
+}
+
+// FirstSeenIn keeps the results of searchID that owner received there for the first time,
+// leaving out those delivered by earlier searches. It also returns how many were left out.
+func FirstSeenIn(owner Owner, searchID string, results []places.PlaceDetails) ([]places.PlaceDetails, int, error) {
+	var externalIDs []string
+	err := database.DB.Model(&models.SearchResult{}).
+		Joins("JOIN seen_places ON seen_places.place_id = search_results.place_id").
+		Joins("JOIN places ON places.id = search_results.place_id").
+		Where("search_results.search_id = ?", searchID).
+		Where("seen_places.user_id = ? AND seen_places.subscription_id = ?", owner.UserID, owner.SubscriptionID).
+		Where("seen_places.first_search_id <> ?", searchID).
+		Pluck("places.external_id", &externalIDs).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to look up seen places: %w", err)
+	}
+
+	seen := make(map[string]bool, len(externalIDs))
+	for _, externalID := range externalIDs {
+		seen[externalID] = true
+	}
+
+	kept := make([]places.PlaceDetails, 0, len(results))
+	for _, place := range results {
+		if !seen[placeKey(place.PlaceID, place.Name, place.FormattedAddress)] {
+			kept = append(kept, place)
+		}
+	}
+
+	return kept, len(results) - len(kept), nil
+}
@@ -0,0 +1,30 @@
+package search
+
+import (
+	"fmt"
+	"medina-consultancy-api/pkg/export"
+	"medina-consultancy-api/pkg/places"
+	"medina-consultancy-api/pkg/supabase"
+)
+
+// UploadCSV renders the results as CSV and stores them in the Supabase bucket under searches/<searchID>.csv.
+func UploadCSV(searchID string, results []places.PlaceDetails) (fileName string, bucketURL string, err error) {
+	fileName = fmt.Sprintf("searches/%s.csv", searchID)
+
+	csvData, err := export.CSV(results, export.DefaultOptions())
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate CSV: %w", err)
+	}
+
+	supabaseClient, err := supabase.NewClient()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create Supabase client: %w", err)
+	}
+
+	bucketURL, err = supabaseClient.UploadFile(fileName, csvData, "text/csv")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to upload CSV to Supabase: %w", err)
+	}
+
+	return fileName, bucketURL, nil
+}
@@ -0,0 +1,30 @@
+package textutil
+
+import "strings"
+
+var accentReplacer = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// Fold lowercases the text, removes Portuguese/Spanish accents and collapses whitespace,
+// so that "São  Paulo" and "sao paulo" compare equal.
+func Fold(text string) string {
+	return strings.Join(strings.Fields(accentReplacer.Replace(strings.ToLower(text))), " ")
+}
+
+// CityKey folds a city name and drops a trailing state or country ("São Paulo - SP",
+// "Curitiba, PR").
+func CityKey(city string) string {
+	if i := strings.IndexAny(city, ",/"); i > 0 {
+		city = city[:i]
+	}
+	if i := strings.Index(city, " - "); i > 0 {
+		city = city[:i]
+	}
+	return Fold(city)
+}